@@ -0,0 +1,203 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: social/v1/like.proto
+
+package socialv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LikeService_LikePost_FullMethodName   = "/social.v1.LikeService/LikePost"
+	LikeService_UnlikePost_FullMethodName = "/social.v1.LikeService/UnlikePost"
+	LikeService_ListLikes_FullMethodName  = "/social.v1.LikeService/ListLikes"
+)
+
+// LikeServiceClient is the client API for LikeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Liking and unliking are idempotent, unlike the REST toggle.
+type LikeServiceClient interface {
+	LikePost(ctx context.Context, in *LikePostRequest, opts ...grpc.CallOption) (*Post, error)
+	UnlikePost(ctx context.Context, in *UnlikePostRequest, opts ...grpc.CallOption) (*Post, error)
+	// ListLikes pages through a user's likes, most recent first.
+	ListLikes(ctx context.Context, in *ListLikesRequest, opts ...grpc.CallOption) (*ListLikesResponse, error)
+}
+
+type likeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLikeServiceClient(cc grpc.ClientConnInterface) LikeServiceClient {
+	return &likeServiceClient{cc}
+}
+
+func (c *likeServiceClient) LikePost(ctx context.Context, in *LikePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Post)
+	err := c.cc.Invoke(ctx, LikeService_LikePost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *likeServiceClient) UnlikePost(ctx context.Context, in *UnlikePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Post)
+	err := c.cc.Invoke(ctx, LikeService_UnlikePost_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *likeServiceClient) ListLikes(ctx context.Context, in *ListLikesRequest, opts ...grpc.CallOption) (*ListLikesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLikesResponse)
+	err := c.cc.Invoke(ctx, LikeService_ListLikes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LikeServiceServer is the server API for LikeService service.
+// All implementations must embed UnimplementedLikeServiceServer
+// for forward compatibility.
+//
+// Liking and unliking are idempotent, unlike the REST toggle.
+type LikeServiceServer interface {
+	LikePost(context.Context, *LikePostRequest) (*Post, error)
+	UnlikePost(context.Context, *UnlikePostRequest) (*Post, error)
+	// ListLikes pages through a user's likes, most recent first.
+	ListLikes(context.Context, *ListLikesRequest) (*ListLikesResponse, error)
+	mustEmbedUnimplementedLikeServiceServer()
+}
+
+// UnimplementedLikeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLikeServiceServer struct{}
+
+func (UnimplementedLikeServiceServer) LikePost(context.Context, *LikePostRequest) (*Post, error) {
+	return nil, status.Error(codes.Unimplemented, "method LikePost not implemented")
+}
+func (UnimplementedLikeServiceServer) UnlikePost(context.Context, *UnlikePostRequest) (*Post, error) {
+	return nil, status.Error(codes.Unimplemented, "method UnlikePost not implemented")
+}
+func (UnimplementedLikeServiceServer) ListLikes(context.Context, *ListLikesRequest) (*ListLikesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListLikes not implemented")
+}
+func (UnimplementedLikeServiceServer) mustEmbedUnimplementedLikeServiceServer() {}
+func (UnimplementedLikeServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeLikeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LikeServiceServer will
+// result in compilation errors.
+type UnsafeLikeServiceServer interface {
+	mustEmbedUnimplementedLikeServiceServer()
+}
+
+func RegisterLikeServiceServer(s grpc.ServiceRegistrar, srv LikeServiceServer) {
+	// If the following call panics, it indicates UnimplementedLikeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LikeService_ServiceDesc, srv)
+}
+
+func _LikeService_LikePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LikePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LikeServiceServer).LikePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LikeService_LikePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LikeServiceServer).LikePost(ctx, req.(*LikePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LikeService_UnlikePost_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlikePostRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LikeServiceServer).UnlikePost(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LikeService_UnlikePost_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LikeServiceServer).UnlikePost(ctx, req.(*UnlikePostRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LikeService_ListLikes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLikesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LikeServiceServer).ListLikes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LikeService_ListLikes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LikeServiceServer).ListLikes(ctx, req.(*ListLikesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LikeService_ServiceDesc is the grpc.ServiceDesc for LikeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LikeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "social.v1.LikeService",
+	HandlerType: (*LikeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LikePost",
+			Handler:    _LikeService_LikePost_Handler,
+		},
+		{
+			MethodName: "UnlikePost",
+			Handler:    _LikeService_UnlikePost_Handler,
+		},
+		{
+			MethodName: "ListLikes",
+			Handler:    _LikeService_ListLikes_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "social/v1/like.proto",
+}
@@ -0,0 +1,117 @@
+// Package audit records security-relevant events without slowing down the
+// requests that trigger them.
+package audit
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+const (
+	queueSize = 1024
+	batchSize = 100
+)
+
+// Logger queues audit events and writes them in batches from a single
+// background goroutine. If the queue is full the event is dropped and the
+// drop is logged, so a slow database never blocks a login.
+type Logger struct {
+	db     *gorm.DB
+	events chan models.AuditEvent
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewLogger(db *gorm.DB) *Logger {
+	return &Logger{
+		db:     db,
+		events: make(chan models.AuditEvent, queueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Record queues event, stamping it with the request's client IP, user agent
+// and the current time.
+func (l *Logger) Record(c *gin.Context, event models.AuditEvent) {
+	event.IP = c.ClientIP()
+	event.UserAgent = truncate(c.Request.UserAgent(), 500)
+	event.Details = truncate(event.Details, 500)
+	event.CreatedAt = time.Now()
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.closed {
+		return
+	}
+
+	select {
+	case l.events <- event:
+	default:
+		log.Printf("audit: queue full, dropped %s event", event.Action)
+	}
+}
+
+// Run writes queued events until Close is called.
+func (l *Logger) Run() {
+	defer close(l.done)
+
+	batch := make([]models.AuditEvent, 0, batchSize)
+	for event := range l.events {
+		batch = append(batch[:0], event)
+	drain:
+		for len(batch) < batchSize {
+			select {
+			case e, ok := <-l.events:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, e)
+			default:
+				break drain
+			}
+		}
+
+		if err := l.db.Create(&batch).Error; err != nil {
+			// Retry one by one so a single bad row cannot take the rest of
+			// the batch with it
+			log.Printf("audit: failed to write %d events, retrying individually: %v", len(batch), err)
+			for i := range batch {
+				if err := l.db.Create(&batch[i]).Error; err != nil {
+					log.Printf("audit: dropped %s event: %v", batch[i].Action, err)
+				}
+			}
+		}
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are written.
+func (l *Logger) Close() {
+	l.mu.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.events)
+	}
+	l.mu.Unlock()
+
+	<-l.done
+}
+
+// truncate cuts s to at most n bytes of valid UTF-8 without NUL bytes.
+// Request headers can carry either, and PostgreSQL rejects both in text
+// columns.
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+	if len(s) <= n {
+		return s
+	}
+	// Cutting mid-rune would leave invalid UTF-8 again
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,49 @@
+// Command mockoidc serves the mock OpenID Connect issuer from oidc/oidctest
+// for trying the sign-in flow locally.
+//
+// With the mock provider from config.example.yaml:
+//
+//	go run ./cmd/mockoidc
+//	curl -c jar -X POST localhost:8080/api/auth/oidc/mock/start
+//	curl -i "<authorization_url>&login_hint=bob@example.com"
+//	curl -b jar "<Location header>"
+//
+// The last call returns a session token for bob@example.com, creating the
+// account on first use. Without login_hint the -email flag is used. The
+// cookie jar carries the cookie that binds the callback to the client that
+// started the flow.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/krisn2/go-social/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9999", "listen address")
+	issuerURL := flag.String("issuer", "", "issuer URL (default http://<addr>)")
+	clientID := flag.String("client-id", "go-social", "the only client accepted")
+	clientSecret := flag.String("client-secret", "mock-secret", "client secret; empty accepts public clients")
+	email := flag.String("email", "alice@example.com", "email signed in when the request has no login_hint")
+	name := flag.String("name", "", "name claim (default the email)")
+	emailVerified := flag.Bool("email-verified", true, "value of the email_verified claim")
+	flag.Parse()
+
+	if *issuerURL == "" {
+		*issuerURL = "http://" + *addr
+	}
+
+	iss, err := oidctest.New(*issuerURL, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+	iss.Name = *name
+	iss.Email = *email
+	iss.EmailVerified = *emailVerified
+
+	log.Printf("Mock OIDC issuer %s listening on %s", iss.URL, *addr)
+	log.Fatal(http.ListenAndServe(*addr, iss.Handler()))
+}
@@ -0,0 +1,43 @@
+// Command recount repairs the denormalized like, comment and repost
+// counters on posts. BenchmarkListPosts in the database package times the
+// post list query that reads them.
+//
+// It reads the same config file, environment variables and -config flag as
+// the server.
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/database"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path to a YAML or JSON config file")
+	flag.Parse()
+
+	var args []string
+	if *configPath != "" {
+		args = []string{"-config", *configPath}
+	}
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg.LogLevel = "error"
+
+	db, err := database.Initialize(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	start := time.Now()
+	fixed, err := database.RepairCounters(db)
+	if err != nil {
+		log.Fatalf("Failed to repair counters: %v", err)
+	}
+	log.Printf("Repaired counters on %d posts in %s", fixed, time.Since(start))
+}
@@ -1,35 +1,447 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultJWTSecret       = "dev_super_secret_change_me"
+	defaultAdminListSecret = "let_me_list_users"
 )
 
+// Config is resolved in layers: built-in defaults, then an optional YAML or
+// JSON file, then environment variables, then command-line flags.
 type Config struct {
-	DatabaseURL     string
-	JWTSecret       string
-	AdminListSecret string
-	Port            string
+	Env             string `yaml:"env"`
+	DatabaseURL     string `yaml:"database_url"`
+	JWTSecret       string `yaml:"jwt_secret"`
+	AdminListSecret string `yaml:"admin_list_secret"`
+	Port            string `yaml:"port"`
+	LogLevel        string `yaml:"log_level"`
+
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	CORS        CORSConfig        `yaml:"cors"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Exports     ExportsConfig     `yaml:"exports"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	Unfurl      UnfurlConfig      `yaml:"unfurl"`
+	Ranking     RankingConfig     `yaml:"ranking"`
+	Recommend   RecommendConfig   `yaml:"recommendations"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+
+	// File is the config file that was loaded, if any.
+	File string `yaml:"-"`
+}
+
+type ServerConfig struct {
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TrustedProxies are the addresses or CIDR ranges whose
+	// X-Forwarded-For header is believed. Empty trusts none, so the client
+	// IP is always the connecting peer.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+type RateLimitConfig struct {
+	Enabled           bool    `yaml:"enabled"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// JobsConfig controls the background jobs that run inside the server.
+type JobsConfig struct {
+	PublishInterval time.Duration `yaml:"publish_interval"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept
+// for replay.
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// ExportsConfig controls personal data export archives.
+type ExportsConfig struct {
+	Dir          string        `yaml:"dir"`           // where finished archives are stored
+	TTL          time.Duration `yaml:"ttl"`           // how long a download link stays valid
+	PollInterval time.Duration `yaml:"poll_interval"` // how often the worker looks for new requests
+}
+
+// GRPCConfig controls the gRPC server that runs next to the HTTP server.
+type GRPCConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Port               string        `yaml:"port"`
+	StreamPollInterval time.Duration `yaml:"stream_poll_interval"` // how often StreamPosts looks for new posts
+}
+
+// UnfurlConfig controls the background fetching of link previews.
+type UnfurlConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	Timeout      time.Duration `yaml:"timeout"`       // per page, including redirects
+	MaxBytes     int           `yaml:"max_bytes"`     // read limit per page
+	PollInterval time.Duration `yaml:"poll_interval"` // how often the worker looks for queued links
+	UserAgent    string        `yaml:"user_agent"`
 }
 
-func Load() *Config {
-	cfg := &Config{
-		DatabaseURL:     getenv("DATABASE_URL", "host=localhost user=postgres password=postgres dbname=go_social port=5432 sslmode=disable"),
-		JWTSecret:       getenv("JWT_SECRET", "dev_super_secret_change_me"),
-		AdminListSecret: getenv("ADMIN_LIST_SECRET", "let_me_list_users"),
-		Port:            getenv("PORT", "8080"),
+// RankingConfig tunes the hot sort. See jobs.HotParams for the formula.
+type RankingConfig struct {
+	Interval      time.Duration `yaml:"interval"` // how often hot scores are recomputed
+	MaxAge        time.Duration `yaml:"max_age"`  // older posts score zero
+	LikeWeight    float64       `yaml:"like_weight"`
+	CommentWeight float64       `yaml:"comment_weight"`
+	Gravity       float64       `yaml:"gravity"`
+}
+
+// RecommendConfig controls the batch job behind follow suggestions and
+// related posts.
+type RecommendConfig struct {
+	Interval   time.Duration `yaml:"interval"`    // how often the results are rebuilt
+	Lookback   time.Duration `yaml:"lookback"`    // only likes this recent count
+	MinOverlap int           `yaml:"min_overlap"` // likes in common needed to count as similar
+	Limit      int           `yaml:"limit"`       // results kept per user and per post
+}
+
+// OIDCConfig lists the OpenID Connect providers users can sign in with.
+// Providers can only be set in the config file.
+type OIDCConfig struct {
+	RedirectURL string         `yaml:"redirect_url"` // callback base; the provider name and /callback are appended
+	StateTTL    time.Duration  `yaml:"state_ttl"`    // how long a sign-in may take at the provider
+	Providers   []OIDCProvider `yaml:"providers"`
+}
+
+// OIDCProvider is one OpenID Connect issuer. Its endpoints are found
+// through discovery.
+type OIDCProvider struct {
+	Name         string   `yaml:"name"` // used in URLs and stored on linked identities
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"` // empty for public clients
+	Scopes       []string `yaml:"scopes"`        // defaults to openid, email and profile
+}
+
+var providerName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+func Default() *Config {
+	return &Config{
+		Env:             "development",
+		DatabaseURL:     "host=localhost user=postgres password=postgres dbname=go_social port=5432 sslmode=disable",
+		JWTSecret:       defaultJWTSecret,
+		AdminListSecret: defaultAdminListSecret,
+		Port:            "8080",
+		LogLevel:        "info",
+		Server: ServerConfig{
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "If-Modified-Since", "Idempotency-Key"},
+			MaxAge:         12 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 20,
+			Burst:             40,
+		},
+		Jobs: JobsConfig{
+			PublishInterval: 30 * time.Second,
+			CleanupInterval: time.Hour,
+		},
+		Exports: ExportsConfig{
+			Dir:          filepath.Join(os.TempDir(), "go-social-exports"),
+			TTL:          24 * time.Hour,
+			PollInterval: 5 * time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		GRPC: GRPCConfig{
+			Enabled:            true,
+			Port:               "9090",
+			StreamPollInterval: 2 * time.Second,
+		},
+		Unfurl: UnfurlConfig{
+			Enabled:      true,
+			Timeout:      5 * time.Second,
+			MaxBytes:     1 << 20,
+			PollInterval: 5 * time.Second,
+			UserAgent:    "go-social-unfurler/1.0",
+		},
+		Ranking: RankingConfig{
+			Interval:      time.Minute,
+			MaxAge:        7 * 24 * time.Hour,
+			LikeWeight:    1,
+			CommentWeight: 2,
+			Gravity:       1.8,
+		},
+		Recommend: RecommendConfig{
+			Interval:   time.Hour,
+			Lookback:   90 * 24 * time.Hour,
+			MinOverlap: 2,
+			Limit:      50,
+		},
+		OIDC: OIDCConfig{
+			RedirectURL: "http://localhost:8080/api/auth/oidc",
+			StateTTL:    10 * time.Minute,
+		},
+	}
+}
+
+// Load builds the effective configuration from defaults, the file named by
+// CONFIG_FILE or -config, the environment and args (usually os.Args[1:]).
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+
+	path := getenv("CONFIG_FILE", "")
+	if p := configPathFromArgs(args); p != "" {
+		path = p
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+		cfg.File = path
 	}
 
-	// Validate critical config
-	if len(cfg.JWTSecret) < 32 {
-		log.Println("WARNING: JWT_SECRET should be at least 32 characters for security")
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
 	}
 
-	if cfg.JWTSecret == "dev_super_secret_change_me" {
-		log.Println("WARNING: Using default JWT secret - change this in production!")
+	if err := cfg.loadFlags(args); err != nil {
+		return nil, err
 	}
 
-	return cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// Validate rejects unusable values. Weak or default secrets are only
+// warnings in development but are fatal when Env is "production".
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Port == "" {
+		errs = append(errs, errors.New("port must not be empty"))
+	}
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("database_url must not be empty"))
+	}
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log_level must be one of debug, info, warn, error (got %q)", c.LogLevel))
+	}
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, errors.New("database.max_open_conns must be at least 1"))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, errors.New("database.max_idle_conns must be between 0 and database.max_open_conns"))
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("server timeouts must be positive"))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("server.trusted_proxies: %q is not an IP address or CIDR range", proxy))
+			}
+		}
+	}
+	if c.RateLimit.Enabled && (c.RateLimit.RequestsPerSecond <= 0 || c.RateLimit.Burst < 1) {
+		errs = append(errs, errors.New("rate_limit.requests_per_second and rate_limit.burst must be positive when rate limiting is enabled"))
+	}
+	if c.Jobs.PublishInterval <= 0 || c.Jobs.CleanupInterval <= 0 {
+		errs = append(errs, errors.New("jobs.publish_interval and jobs.cleanup_interval must be positive"))
+	}
+	if c.Idempotency.TTL <= 0 {
+		errs = append(errs, errors.New("idempotency.ttl must be positive"))
+	}
+	if c.Exports.Dir == "" {
+		errs = append(errs, errors.New("exports.dir must not be empty"))
+	}
+	if c.Exports.TTL <= 0 || c.Exports.PollInterval <= 0 {
+		errs = append(errs, errors.New("exports.ttl and exports.poll_interval must be positive"))
+	}
+	if c.GRPC.Enabled {
+		if c.GRPC.Port == "" || c.GRPC.Port == c.Port {
+			errs = append(errs, errors.New("grpc.port must be set and differ from port"))
+		}
+		if c.GRPC.StreamPollInterval <= 0 {
+			errs = append(errs, errors.New("grpc.stream_poll_interval must be positive"))
+		}
+	}
+	if c.Unfurl.Enabled && (c.Unfurl.Timeout <= 0 || c.Unfurl.MaxBytes < 1 || c.Unfurl.PollInterval <= 0) {
+		errs = append(errs, errors.New("unfurl.timeout, unfurl.max_bytes and unfurl.poll_interval must be positive"))
+	}
+	if c.Ranking.Interval <= 0 || c.Ranking.MaxAge <= 0 {
+		errs = append(errs, errors.New("ranking.interval and ranking.max_age must be positive"))
+	}
+	if c.Ranking.LikeWeight < 0 || c.Ranking.CommentWeight < 0 || c.Ranking.Gravity <= 0 {
+		errs = append(errs, errors.New("ranking weights must not be negative and ranking.gravity must be positive"))
+	}
+	if c.Recommend.Interval <= 0 || c.Recommend.Lookback <= 0 || c.Recommend.MinOverlap < 1 || c.Recommend.Limit < 1 {
+		errs = append(errs, errors.New("recommendations.interval, lookback, min_overlap and limit must be positive"))
+	}
+	if c.OIDC.StateTTL <= 0 {
+		errs = append(errs, errors.New("oidc.state_ttl must be positive"))
+	}
+	if len(c.OIDC.Providers) > 0 {
+		if u, err := url.Parse(c.OIDC.RedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, errors.New("oidc.redirect_url must be an absolute URL when providers are configured"))
+		}
+	}
+	seenProviders := make(map[string]bool, len(c.OIDC.Providers))
+	for i, p := range c.OIDC.Providers {
+		if !providerName.MatchString(p.Name) {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].name must be lowercase letters, digits and dashes", i))
+		} else if seenProviders[p.Name] {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].name %q is used twice", i, p.Name))
+		}
+		seenProviders[p.Name] = true
+		if p.ClientID == "" {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].client_id must not be empty", i))
+		}
+		u, err := url.Parse(p.Issuer)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].issuer must be an absolute URL", i))
+		} else if c.IsProduction() && u.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("oidc.providers[%d].issuer must use https in production", i))
+		}
+	}
+
+	if c.CORS.AllowCredentials && containsString(c.CORS.AllowedOrigins, "*") {
+		errs = append(errs, errors.New("cors.allow_credentials cannot be combined with a wildcard origin"))
+	}
+
+	weakJWT := len(c.JWTSecret) < 32
+	defaultJWT := c.JWTSecret == defaultJWTSecret
+	defaultAdmin := c.AdminListSecret == defaultAdminListSecret
+
+	if c.IsProduction() {
+		if defaultJWT {
+			errs = append(errs, errors.New("jwt_secret must be changed from the default in production"))
+		} else if weakJWT {
+			errs = append(errs, errors.New("jwt_secret must be at least 32 characters in production"))
+		}
+		if defaultAdmin {
+			errs = append(errs, errors.New("admin_list_secret must be changed from the default in production"))
+		}
+	} else {
+		if weakJWT {
+			log.Println("WARNING: JWT_SECRET should be at least 32 characters for security")
+		}
+		if defaultJWT {
+			log.Println("WARNING: Using default JWT secret - change this in production!")
+		}
+		if defaultAdmin {
+			log.Println("WARNING: Using default ADMIN_LIST_SECRET - change this in production!")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Effective returns one "key = value" line per setting with secrets redacted.
+func (c *Config) Effective() []string {
+	settings := c.settings()
+	lines := make([]string, 0, len(settings))
+	for _, s := range settings {
+		value := s.value.String()
+		if s.redact != nil {
+			value = s.redact(value)
+		}
+		lines = append(lines, fmt.Sprintf("%s = %s", s.key, value))
+	}
+	return lines
+}
+
+// LogEffective writes the effective configuration to the standard logger.
+func (c *Config) LogEffective() {
+	source := "defaults and environment"
+	if c.File != "" {
+		source = c.File
+	}
+	log.Printf("Effective configuration (env=%s, file=%s):", c.Env, source)
+	for _, line := range c.Effective() {
+		log.Printf("  %s", line)
+	}
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	// JSON is a subset of YAML, so one decoder handles both formats.
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	for _, s := range c.settings() {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.value.Set(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", s.env, err)
+		}
+	}
+	return nil
 }
 
 func getenv(key, defaultValue string) string {
@@ -38,3 +450,12 @@ func getenv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting ties one config field to its environment variable and flag so the
+// env loader, the flag parser and the effective-config dump share one list.
+type setting struct {
+	key    string // dotted key, also used to derive the flag name
+	env    string
+	usage  string
+	value  flag.Value
+	redact func(string) string
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "env", env: "APP_ENV", usage: "runtime environment (development or production)", value: (*stringValue)(&c.Env)},
+		{key: "port", env: "PORT", usage: "HTTP listen port", value: (*stringValue)(&c.Port)},
+		{key: "log_level", env: "LOG_LEVEL", usage: "log level (debug, info, warn, error)", value: (*stringValue)(&c.LogLevel)},
+		{key: "database_url", env: "DATABASE_URL", usage: "PostgreSQL connection string", value: (*stringValue)(&c.DatabaseURL), redact: redactDSN},
+		{key: "jwt_secret", env: "JWT_SECRET", usage: "HMAC secret used to sign JWTs", value: (*stringValue)(&c.JWTSecret), redact: redactSecret},
+		{key: "admin_list_secret", env: "ADMIN_LIST_SECRET", usage: "secret required to list users", value: (*stringValue)(&c.AdminListSecret), redact: redactSecret},
+
+		{key: "server.read_timeout", env: "SERVER_READ_TIMEOUT", usage: "HTTP read timeout", value: (*durationValue)(&c.Server.ReadTimeout)},
+		{key: "server.write_timeout", env: "SERVER_WRITE_TIMEOUT", usage: "HTTP write timeout", value: (*durationValue)(&c.Server.WriteTimeout)},
+		{key: "server.idle_timeout", env: "SERVER_IDLE_TIMEOUT", usage: "HTTP keep-alive idle timeout", value: (*durationValue)(&c.Server.IdleTimeout)},
+		{key: "server.trusted_proxies", env: "SERVER_TRUSTED_PROXIES", usage: "comma-separated proxy addresses or CIDR ranges allowed to set X-Forwarded-For", value: (*listValue)(&c.Server.TrustedProxies)},
+		{key: "server.shutdown_timeout", env: "SERVER_SHUTDOWN_TIMEOUT", usage: "grace period for in-flight requests on shutdown", value: (*durationValue)(&c.Server.ShutdownTimeout)},
+
+		{key: "database.max_open_conns", env: "DB_MAX_OPEN_CONNS", usage: "maximum open database connections", value: (*intValue)(&c.Database.MaxOpenConns)},
+		{key: "database.max_idle_conns", env: "DB_MAX_IDLE_CONNS", usage: "maximum idle database connections", value: (*intValue)(&c.Database.MaxIdleConns)},
+		{key: "database.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", usage: "maximum lifetime of a database connection", value: (*durationValue)(&c.Database.ConnMaxLifetime)},
+		{key: "database.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", usage: "maximum idle time of a database connection", value: (*durationValue)(&c.Database.ConnMaxIdleTime)},
+
+		{key: "cors.allowed_origins", env: "CORS_ALLOWED_ORIGINS", usage: "comma-separated allowed origins", value: (*listValue)(&c.CORS.AllowedOrigins)},
+		{key: "cors.allowed_methods", env: "CORS_ALLOWED_METHODS", usage: "comma-separated allowed methods", value: (*listValue)(&c.CORS.AllowedMethods)},
+		{key: "cors.allowed_headers", env: "CORS_ALLOWED_HEADERS", usage: "comma-separated allowed request headers", value: (*listValue)(&c.CORS.AllowedHeaders)},
+		{key: "cors.allow_credentials", env: "CORS_ALLOW_CREDENTIALS", usage: "allow credentialed cross-origin requests", value: (*boolValue)(&c.CORS.AllowCredentials)},
+		{key: "cors.max_age", env: "CORS_MAX_AGE", usage: "how long browsers may cache preflight responses", value: (*durationValue)(&c.CORS.MaxAge)},
+
+		{key: "rate_limit.enabled", env: "RATE_LIMIT_ENABLED", usage: "enable per-client rate limiting", value: (*boolValue)(&c.RateLimit.Enabled)},
+		{key: "rate_limit.requests_per_second", env: "RATE_LIMIT_RPS", usage: "sustained requests per second per client", value: (*floatValue)(&c.RateLimit.RequestsPerSecond)},
+		{key: "rate_limit.burst", env: "RATE_LIMIT_BURST", usage: "request burst allowed per client", value: (*intValue)(&c.RateLimit.Burst)},
+
+		{key: "jobs.publish_interval", env: "JOBS_PUBLISH_INTERVAL", usage: "how often scheduled posts are checked for publishing", value: (*durationValue)(&c.Jobs.PublishInterval)},
+		{key: "jobs.cleanup_interval", env: "JOBS_CLEANUP_INTERVAL", usage: "how often expired idempotency keys are purged", value: (*durationValue)(&c.Jobs.CleanupInterval)},
+
+		{key: "exports.dir", env: "EXPORTS_DIR", usage: "directory for personal data export archives", value: (*stringValue)(&c.Exports.Dir)},
+		{key: "exports.ttl", env: "EXPORTS_TTL", usage: "how long an export download link stays valid", value: (*durationValue)(&c.Exports.TTL)},
+		{key: "exports.poll_interval", env: "EXPORTS_POLL_INTERVAL", usage: "how often the export worker looks for new requests", value: (*durationValue)(&c.Exports.PollInterval)},
+
+		{key: "idempotency.ttl", env: "IDEMPOTENCY_TTL", usage: "how long Idempotency-Key responses are kept for replay", value: (*durationValue)(&c.Idempotency.TTL)},
+
+		{key: "grpc.enabled", env: "GRPC_ENABLED", usage: "serve the gRPC API", value: (*boolValue)(&c.GRPC.Enabled)},
+		{key: "grpc.port", env: "GRPC_PORT", usage: "gRPC listen port", value: (*stringValue)(&c.GRPC.Port)},
+		{key: "grpc.stream_poll_interval", env: "GRPC_STREAM_POLL_INTERVAL", usage: "how often StreamPosts looks for new posts", value: (*durationValue)(&c.GRPC.StreamPollInterval)},
+
+		{key: "unfurl.enabled", env: "UNFURL_ENABLED", usage: "fetch link previews for posts", value: (*boolValue)(&c.Unfurl.Enabled)},
+		{key: "unfurl.timeout", env: "UNFURL_TIMEOUT", usage: "time limit for fetching one page", value: (*durationValue)(&c.Unfurl.Timeout)},
+		{key: "unfurl.max_bytes", env: "UNFURL_MAX_BYTES", usage: "maximum bytes read from one page", value: (*intValue)(&c.Unfurl.MaxBytes)},
+		{key: "unfurl.poll_interval", env: "UNFURL_POLL_INTERVAL", usage: "how often the unfurler looks for queued links", value: (*durationValue)(&c.Unfurl.PollInterval)},
+		{key: "unfurl.user_agent", env: "UNFURL_USER_AGENT", usage: "User-Agent sent when fetching previews", value: (*stringValue)(&c.Unfurl.UserAgent)},
+
+		{key: "ranking.interval", env: "RANKING_INTERVAL", usage: "how often hot scores are recomputed", value: (*durationValue)(&c.Ranking.Interval)},
+		{key: "ranking.max_age", env: "RANKING_MAX_AGE", usage: "age after which posts drop out of the hot sort", value: (*durationValue)(&c.Ranking.MaxAge)},
+		{key: "ranking.like_weight", env: "RANKING_LIKE_WEIGHT", usage: "weight of a like in the hot score", value: (*floatValue)(&c.Ranking.LikeWeight)},
+		{key: "ranking.comment_weight", env: "RANKING_COMMENT_WEIGHT", usage: "weight of a comment in the hot score", value: (*floatValue)(&c.Ranking.CommentWeight)},
+		{key: "ranking.gravity", env: "RANKING_GRAVITY", usage: "how fast hot scores decay with age", value: (*floatValue)(&c.Ranking.Gravity)},
+
+		{key: "recommendations.interval", env: "RECOMMENDATIONS_INTERVAL", usage: "how often suggestions and related posts are rebuilt", value: (*durationValue)(&c.Recommend.Interval)},
+		{key: "recommendations.lookback", env: "RECOMMENDATIONS_LOOKBACK", usage: "only likes this recent feed recommendations", value: (*durationValue)(&c.Recommend.Lookback)},
+		{key: "recommendations.min_overlap", env: "RECOMMENDATIONS_MIN_OVERLAP", usage: "likes in common needed to count as similar", value: (*intValue)(&c.Recommend.MinOverlap)},
+		{key: "recommendations.limit", env: "RECOMMENDATIONS_LIMIT", usage: "results kept per user and per post", value: (*intValue)(&c.Recommend.Limit)},
+
+		{key: "oidc.redirect_url", env: "OIDC_REDIRECT_URL", usage: "base URL of the OpenID Connect callbacks", value: (*stringValue)(&c.OIDC.RedirectURL)},
+		{key: "oidc.state_ttl", env: "OIDC_STATE_TTL", usage: "how long an OpenID Connect sign-in may take", value: (*durationValue)(&c.OIDC.StateTTL)},
+	}
+}
+
+func flagName(key string) string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(key)
+}
+
+// loadFlags parses args into a copy of the defaults and applies only the
+// flags that were set. Binding the flags to c directly would print the
+// secrets loaded from the file and environment as defaults in -h and flag
+// errors.
+func (c *Config) loadFlags(args []string) error {
+	fs := flag.NewFlagSet("go-social", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.String("config", "", "path to a YAML or JSON config file")
+
+	settings := c.settings()
+	parsed := Default().settings()
+	index := make(map[string]int, len(parsed))
+	for i, s := range parsed {
+		name := flagName(s.key)
+		index[name] = i
+		var value flag.Value = s.value
+		if s.redact != nil {
+			value = &redactedValue{Value: s.value, redact: s.redact}
+		}
+		fs.Var(value, name, s.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("invalid command-line flags: %w", err)
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		i, ok := index[f.Name]
+		if !ok || err != nil {
+			return
+		}
+		if setErr := settings[i].value.Set(parsed[i].value.String()); setErr != nil {
+			err = fmt.Errorf("invalid value for -%s: %w", f.Name, setErr)
+		}
+	})
+	return err
+}
+
+// redactedValue shows a secret setting's default redacted in flag help.
+type redactedValue struct {
+	flag.Value
+	redact func(string) string
+}
+
+func (v *redactedValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.redact(v.Value.String())
+}
+
+// configPathFromArgs finds -config before the full flag set is parsed, since
+// the file layer has to be applied before the environment and the flags.
+func configPathFromArgs(args []string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if v, ok := strings.CutPrefix(name, "config="); ok {
+			return v
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+var dsnPassword = regexp.MustCompile(`(password=)\S+`)
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error { *v = stringValue(s); return nil }
+func (v *stringValue) String() string     { return string(*v) }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v = intValue(n)
+	return nil
+}
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*v = floatValue(f)
+	return nil
+}
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = boolValue(b)
+	return nil
+}
+func (v *boolValue) String() string   { return strconv.FormatBool(bool(*v)) }
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*v = durationValue(d)
+	return nil
+}
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*v = items
+	return nil
+}
+func (v *listValue) String() string { return strings.Join(*v, ",") }
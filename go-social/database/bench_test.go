@@ -0,0 +1,65 @@
+package database
+
+import (
+	"os"
+	"testing"
+
+	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// BenchmarkListPosts compares the post list query reading the counter
+// columns with the per-row COUNT subqueries they replaced. It needs a
+// database with some data in it:
+//
+//	DATABASE_URL=postgres://... go test -run '^$' -bench ListPosts ./database
+func BenchmarkListPosts(b *testing.B) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		b.Skip("DATABASE_URL not set")
+	}
+
+	cfg := config.Default()
+	cfg.DatabaseURL = dsn
+	cfg.LogLevel = "error"
+	db, err := Initialize(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	db = db.Session(&gorm.Session{Logger: logger.Discard})
+
+	const pageSize = 20
+
+	b.Run("count_subqueries", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var posts []struct {
+				models.Post
+				Likes    int64
+				Comments int64
+			}
+			if err := db.Table("posts").
+				Select(`posts.*,
+					(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id) AS likes,
+					(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) AS comments`).
+				Order("posts.id DESC").
+				Limit(pageSize).
+				Find(&posts).Error; err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("counter_columns", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var posts []models.Post
+			if err := db.Model(&models.Post{}).
+				Order("posts.id DESC").
+				Limit(pageSize).
+				Find(&posts).Error; err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
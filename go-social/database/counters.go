@@ -0,0 +1,51 @@
+package database
+
+import (
+	"gorm.io/gorm"
+)
+
+const repairBatchSize = 500
+
+// RepairCounters recomputes posts.likes_count, comments_count and
+// repost_count from the likes, comments and reposting posts and returns how
+// many posts were corrected.
+//
+// Posts are locked in batches before counting. Like and comment writers
+// update the same post rows inside their transactions, so they serialize
+// with the repair and no concurrent change is lost or counted twice.
+func RepairCounters(db *gorm.DB) (int64, error) {
+	var fixed int64
+	var lastID uint
+
+	for {
+		var ids []uint
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Raw("SELECT id FROM posts WHERE id > ? ORDER BY id LIMIT ? FOR UPDATE", lastID, repairBatchSize).
+				Scan(&ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+
+			result := tx.Exec(`UPDATE posts SET likes_count = c.likes, comments_count = c.comments, repost_count = c.reposts, activity_at = NOW()
+				FROM (
+					SELECT p.id,
+						(SELECT COUNT(*) FROM likes WHERE likes.post_id = p.id) AS likes,
+						(SELECT COUNT(*) FROM comments WHERE comments.post_id = p.id) AS comments,
+						(SELECT COUNT(*) FROM posts r WHERE r.repost_of_id = p.id OR r.quote_of_id = p.id) AS reposts
+					FROM posts p WHERE p.id IN ?
+				) AS c
+				WHERE posts.id = c.id AND (posts.likes_count <> c.likes OR posts.comments_count <> c.comments OR posts.repost_count <> c.reposts)`, ids)
+			fixed += result.RowsAffected
+			return result.Error
+		})
+		if err != nil {
+			return fixed, err
+		}
+		if len(ids) < repairBatchSize {
+			return fixed, nil
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
@@ -3,15 +3,17 @@ package database
 import (
 	"fmt"
 
+	"github.com/krisn2/go-social/config"
 	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-func Initialize(databaseURL string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // Enable SQL logging
+func Initialize(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{
+		Logger: logger.Default.LogMode(logLevel(cfg.LogLevel)),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -27,12 +29,48 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	// Counters added to an existing posts table start at zero and need one
+	// full recount
+	needsRecount := !db.Migrator().HasColumn(&models.Post{}, "likes_count")
+
 	// Auto-migrate models
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
 		&models.Like{},
 		&models.Comment{},
+		&models.Block{},
+		&models.Mute{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.Warning{},
+		&models.Bookmark{},
+		&models.BookmarkCollection{},
+		&models.Follow{},
+		&models.DataExport{},
+		&models.AuditEvent{},
+		&models.IdempotencyKey{},
+		&models.LinkPreview{},
+		&models.UserSuggestion{},
+		&models.RelatedPost{},
+		&models.Conversation{},
+		&models.Participant{},
+		&models.Message{},
+		&models.Community{},
+		&models.CommunityMember{},
+		&models.JoinRequest{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollBallot{},
+		&models.PollVote{},
+		&models.AccessToken{},
+		&models.Identity{},
+		&models.OIDCState{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("migration failed: %w", err)
@@ -43,6 +81,20 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to create indexes: %w", err)
 	}
 
+	if err := protectAuditLog(db); err != nil {
+		return nil, fmt.Errorf("failed to protect audit log: %w", err)
+	}
+
+	if err := backfill(db); err != nil {
+		return nil, fmt.Errorf("failed to backfill data: %w", err)
+	}
+
+	if needsRecount {
+		if _, err := RepairCounters(db); err != nil {
+			return nil, fmt.Errorf("failed to compute post counters: %w", err)
+		}
+	}
+
 	return db, nil
 }
 
@@ -52,14 +104,185 @@ func createIndexes(db *gorm.DB) error {
 		return err
 	}
 
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_blocks_pair ON blocks (blocker_id, blocked_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_mutes_pair ON mutes (muter_id, muted_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmarks_user_post ON bookmarks (user_id, post_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_bookmark_collections_user_name ON bookmark_collections (user_id, name)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_follows_pair ON follows (follower_id, following_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_user_key ON idempotency_keys (user_id, key)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_user_suggestions_pair ON user_suggestions (user_id, suggested_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_related_posts_pair ON related_posts (post_id, related_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_participants_conversation_user ON participants (conversation_id, user_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_community_members_pair ON community_members (community_id, user_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_join_requests_pair ON join_requests (community_id, user_id)").Error; err != nil {
+		return err
+	}
+
+	// One account per external identity
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_identities_provider_subject ON identities (provider, subject)").Error; err != nil {
+		return err
+	}
+
+	// One plain repost per user and post
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_posts_user_repost ON posts (user_id, repost_of_id) WHERE repost_of_id IS NOT NULL").Error; err != nil {
+		return err
+	}
+
+	// One ballot per user and poll, each option at most once on it
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_ballots_poll_user ON poll_ballots (poll_id, user_id)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_poll_votes_ballot_option ON poll_votes (ballot_id, option_id)").Error; err != nil {
+		return err
+	}
+
+	// One open report per reporter and target
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_unique ON reports (reporter_id, target_type, target_id) WHERE status = 'open'").Error; err != nil {
+		return err
+	}
+
 	// Performance indexes
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts (created_at DESC)").Error; err != nil {
 		return err
 	}
 
+	// Ranked listings read in index order with the ID as tie-breaker
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_hot ON posts (hot_score DESC, id DESC)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_top ON posts (likes_count DESC, id DESC)").Error; err != nil {
+		return err
+	}
+
+	// Conversations page through messages by ID
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages (conversation_id, id DESC)").Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_conversations_activity ON conversations (activity_at DESC, id DESC)").Error; err != nil {
+		return err
+	}
+
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_comments_post_created ON comments (post_id, created_at)").Error; err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// protectAuditLog makes audit_events append-only at the database level, so
+// not even a buggy handler can rewrite history.
+func protectAuditLog(db *gorm.DB) error {
+	if err := db.Exec(`CREATE OR REPLACE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec("DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events").Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE TRIGGER audit_events_append_only
+		BEFORE UPDATE OR DELETE ON audit_events
+		FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change()`).Error
+}
+
+// backfill fills columns added after rows already existed. Each statement
+// is idempotent.
+func backfill(db *gorm.DB) error {
+	// Posts created before scheduling existed were published on creation
+	if err := db.Exec("UPDATE posts SET published_at = created_at WHERE published_at IS NULL AND status = 'published'").Error; err != nil {
+		return err
+	}
+	if err := db.Exec("UPDATE posts SET live_at = published_at WHERE live_at IS NULL AND status = 'published'").Error; err != nil {
+		return err
+	}
+
+	// Bodies written before Markdown rendering have no cached HTML yet
+	if err := renderBodies(db, "posts"); err != nil {
+		return err
+	}
+	if err := renderBodies(db, "comments"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// renderBodies fills body_html for rows of table that lack it, in batches.
+func renderBodies(db *gorm.DB, table string) error {
+	type row struct {
+		ID   uint
+		Body string
+	}
+
+	for {
+		var rows []row
+		if err := db.Table(table).Select("id, body").Where("body_html IS NULL").Order("id").Limit(500).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, r := range rows {
+				if err := tx.Table(table).Where("id = ?", r.ID).Update("body_html", utils.RenderMarkdown(r.Body)).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// logLevel maps the application log level onto GORM's. SQL statements are
+// logged at info and debug, matching the previous always-on behaviour.
+func logLevel(level string) logger.LogLevel {
+	switch level {
+	case "debug", "info":
+		return logger.Info
+	case "warn":
+		return logger.Warn
+	default:
+		return logger.Error
+	}
+}
@@ -0,0 +1,187 @@
+package gql
+
+import (
+	"github.com/krisn2/go-social/service"
+	"gorm.io/gorm"
+)
+
+// connectionArgs are the paging arguments. The schema defaults first to 20.
+type connectionArgs struct {
+	First int32
+	After *string
+}
+
+func (a connectionArgs) after() string {
+	if a.After == nil {
+		return ""
+	}
+	return *a.After
+}
+
+type pageInfo struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p pageInfo) HasNextPage() bool  { return p.hasNext }
+func (p pageInfo) EndCursor() *string { return p.endCursor }
+
+type postConnection struct {
+	edges []*postEdge
+	info  pageInfo
+}
+
+func (c *postConnection) Edges() []*postEdge { return c.edges }
+func (c *postConnection) PageInfo() pageInfo { return c.info }
+
+type postEdge struct {
+	cursor string
+	node   *postResolver
+}
+
+func (e *postEdge) Cursor() string      { return e.cursor }
+func (e *postEdge) Node() *postResolver { return e.node }
+
+// postConnection pages through the posts the caller can see, newest first,
+// narrowed by scopes that must keep to published posts. Authors, like and
+// bookmark state for the page are loaded in one query each.
+func (r *request) postConnection(args connectionArgs, scopes ...func(*gorm.DB) *gorm.DB) (*postConnection, error) {
+	posts, more, err := r.svc.PostPage(r.viewer, int(args.First), args.after(), scopes...)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	conn := &postConnection{edges: []*postEdge{}, info: pageInfo{hasNext: more}}
+	var postIDs, userIDs []uint
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		userIDs = append(userIDs, post.UserID)
+	}
+	if err := r.primePosts(postIDs, userIDs); err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		conn.edges = append(conn.edges, &postEdge{cursor: service.PostCursor(post), node: &postResolver{r: r, post: post}})
+	}
+	if n := len(conn.edges); n > 0 {
+		conn.info.endCursor = &conn.edges[n-1].cursor
+	}
+	return conn, nil
+}
+
+func (r *request) primePosts(postIDs, authorIDs []uint) error {
+	if err := r.users.prime(authorIDs); err != nil {
+		return internalError()
+	}
+	if r.viewer.ID == 0 {
+		return nil
+	}
+	if err := r.liked.prime(postIDs); err != nil {
+		return internalError()
+	}
+	if err := r.bookmarked.prime(postIDs); err != nil {
+		return internalError()
+	}
+	return nil
+}
+
+type commentConnection struct {
+	edges []*commentEdge
+	info  pageInfo
+}
+
+func (c *commentConnection) Edges() []*commentEdge { return c.edges }
+func (c *commentConnection) PageInfo() pageInfo    { return c.info }
+
+type commentEdge struct {
+	cursor string
+	node   *commentResolver
+}
+
+func (e *commentEdge) Cursor() string         { return e.cursor }
+func (e *commentEdge) Node() *commentResolver { return e.node }
+
+// commentConnection pages through the comments the caller may read on a
+// post they can see, oldest first.
+func (r *request) commentConnection(postID uint, args connectionArgs) (*commentConnection, error) {
+	comments, more, err := r.svc.CommentPage(r.viewer, postID, int(args.First), args.after())
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	conn := &commentConnection{edges: []*commentEdge{}, info: pageInfo{hasNext: more}}
+	var userIDs []uint
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	if err := r.users.prime(userIDs); err != nil {
+		return nil, internalError()
+	}
+
+	for _, comment := range comments {
+		conn.edges = append(conn.edges, &commentEdge{
+			cursor: service.CommentCursor(comment),
+			node:   &commentResolver{r: r, comment: comment},
+		})
+	}
+	if n := len(conn.edges); n > 0 {
+		conn.info.endCursor = &conn.edges[n-1].cursor
+	}
+	return conn, nil
+}
+
+type likeConnection struct {
+	edges []*likeEdge
+	info  pageInfo
+}
+
+func (c *likeConnection) Edges() []*likeEdge { return c.edges }
+func (c *likeConnection) PageInfo() pageInfo { return c.info }
+
+type likeEdge struct {
+	cursor string
+	node   *likeResolver
+}
+
+func (e *likeEdge) Cursor() string      { return e.cursor }
+func (e *likeEdge) Node() *likeResolver { return e.node }
+
+// likeConnection pages through a user's likes on posts the caller can see
+// in listings, most recent first.
+func (r *request) likeConnection(userID uint, args connectionArgs) (*likeConnection, error) {
+	likes, more, err := r.svc.LikePage(r.viewer, userID, int(args.First), args.after())
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+
+	conn := &likeConnection{edges: []*likeEdge{}, info: pageInfo{hasNext: more}}
+	var postIDs []uint
+	for _, like := range likes {
+		postIDs = append(postIDs, like.PostID)
+	}
+	if err := r.posts.prime(postIDs); err != nil {
+		return nil, internalError()
+	}
+
+	userIDs := []uint{userID}
+	for _, id := range postIDs {
+		if post, ok, _ := r.posts.load(id); ok {
+			userIDs = append(userIDs, post.UserID)
+		}
+	}
+	if err := r.primePosts(postIDs, userIDs); err != nil {
+		return nil, err
+	}
+
+	for _, like := range likes {
+		conn.edges = append(conn.edges, &likeEdge{
+			cursor: service.LikeCursor(like),
+			node:   &likeResolver{r: r, like: like},
+		})
+	}
+	if n := len(conn.edges); n > 0 {
+		conn.info.endCursor = &conn.edges[n-1].cursor
+	}
+	return conn, nil
+}
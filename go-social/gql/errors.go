@@ -0,0 +1,68 @@
+package gql
+
+import (
+	"errors"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/krisn2/go-social/service"
+)
+
+// resolverError is reported in the GraphQL errors list with a machine
+// readable extensions.code.
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+var codeNames = map[service.Code]string{
+	service.CodeInvalid:            "BAD_USER_INPUT",
+	service.CodeUnauthenticated:    "UNAUTHENTICATED",
+	service.CodeForbidden:          "FORBIDDEN",
+	service.CodeNotFound:           "NOT_FOUND",
+	service.CodeConflict:           "CONFLICT",
+	service.CodePreconditionFailed: "PRECONDITION_FAILED",
+	service.CodeInternal:           "INTERNAL",
+}
+
+// toGraphQLError keeps the caller-safe message of a service error and
+// hides anything else, such as database errors.
+func toGraphQLError(err error) error {
+	var e *service.Error
+	if !errors.As(err, &e) {
+		return &resolverError{message: "internal server error", code: "INTERNAL"}
+	}
+	return &resolverError{message: e.Message, code: codeNames[e.Code]}
+}
+
+func badInput(msg string) error {
+	return &resolverError{message: msg, code: "BAD_USER_INPUT"}
+}
+
+func forbidden(msg string) error {
+	return &resolverError{message: msg, code: "FORBIDDEN"}
+}
+
+func internalError() error {
+	return &resolverError{message: "internal server error", code: "INTERNAL"}
+}
+
+func parseID(id graphql.ID) (uint, error) {
+	n, err := strconv.ParseUint(string(id), 10, 32)
+	if err != nil {
+		return 0, badInput("invalid ID")
+	}
+	return uint(n), nil
+}
+
+func marshalID(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
@@ -0,0 +1,125 @@
+// Package gql serves a GraphQL API over the same domain rules as the REST
+// handlers. Reads and writes go through package service, so visibility,
+// blocks and ownership checks cannot drift between the two.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"gorm.io/gorm"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+const (
+	maxDepth       = 10
+	maxParallelism = 10
+)
+
+type Handler struct {
+	db     *gorm.DB
+	svc    *service.Service
+	schema *graphql.Schema
+}
+
+func NewHandler(db *gorm.DB) *Handler {
+	h := &Handler{db: db, svc: service.New(db)}
+	h.schema = graphql.MustParseSchema(schemaSDL, &resolver{},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxParallelism),
+	)
+	return h
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Serve executes one GraphQL request. It must run after OptionalJWTAuth so
+// the caller, if any, is known. Personal access tokens need posts:read to
+// query and posts:write to run mutations.
+func (h *Handler) Serve(c *gin.Context) {
+	if !middleware.TokenAllows(c, "posts:read") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "token lacks the posts:read scope"})
+		return
+	}
+
+	var req graphQLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	r := h.newRequest(service.LoadViewer(h.db, userID))
+	r.canWrite = middleware.TokenAllows(c, "posts:write")
+	ctx := withRequest(c.Request.Context(), r)
+
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// request is the state shared by all resolvers of one GraphQL request.
+type request struct {
+	db     *gorm.DB
+	svc    *service.Service
+	viewer service.Viewer
+	// canWrite is false for access tokens without posts:write
+	canWrite bool
+
+	users      *loader[models.User]
+	posts      *loader[models.Post]
+	liked      *loader[bool]
+	bookmarked *loader[bool]
+}
+
+func (h *Handler) newRequest(v service.Viewer) *request {
+	r := &request{db: h.db, svc: h.svc, viewer: v}
+
+	r.users = newLoader(h.svc.UsersByID)
+	r.posts = newLoader(func(ids []uint) (map[uint]models.Post, error) {
+		return h.svc.PostsByID(v, ids)
+	})
+	r.liked = newLoader(func(ids []uint) (map[uint]bool, error) {
+		return service.LikedSet(h.db, v.ID, ids), nil
+	})
+	r.bookmarked = newLoader(func(ids []uint) (map[uint]bool, error) {
+		return service.BookmarkedSet(h.db, v.ID, ids), nil
+	})
+	return r
+}
+
+func (r *request) checkWrite() error {
+	if !r.canWrite {
+		return forbidden("token lacks the posts:write scope")
+	}
+	return nil
+}
+
+type requestKey struct{}
+
+func withRequest(ctx context.Context, r *request) context.Context {
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
@@ -0,0 +1,70 @@
+package gql
+
+import "sync"
+
+// loader batches lookups by ID for one request. Connections prime it with
+// every ID on the page in a single query, so resolving the fields of each
+// node hits the cache instead of the database, and anything not primed is
+// fetched on first use. It is safe for the concurrent field resolution
+// graphql-go does.
+type loader[V any] struct {
+	fetch func(ids []uint) (map[uint]V, error)
+
+	mu      sync.Mutex
+	values  map[uint]V
+	fetched map[uint]bool
+}
+
+func newLoader[V any](fetch func(ids []uint) (map[uint]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, values: map[uint]V{}, fetched: map[uint]bool{}}
+}
+
+// prime fetches every ID not yet known in one call.
+func (l *loader[V]) prime(ids []uint) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var missing []uint
+	for _, id := range ids {
+		if !l.fetched[id] {
+			l.fetched[id] = true
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	values, err := l.fetch(missing)
+	if err != nil {
+		for _, id := range missing {
+			delete(l.fetched, id)
+		}
+		return err
+	}
+	for id, v := range values {
+		l.values[id] = v
+	}
+	return nil
+}
+
+// load returns the value for id and whether it exists.
+func (l *loader[V]) load(id uint) (V, bool, error) {
+	if err := l.prime([]uint{id}); err != nil {
+		var zero V
+		return zero, false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	v, ok := l.values[id]
+	return v, ok, nil
+}
+
+// forget drops id so the next load fetches it again, after a mutation.
+func (l *loader[V]) forget(id uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.values, id)
+	delete(l.fetched, id)
+}
@@ -0,0 +1,168 @@
+package gql
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/krisn2/go-social/service"
+)
+
+type createPostInput struct {
+	Title      string
+	Body       *string
+	Status     *string
+	PublishAt  *graphql.Time
+	Visibility *string
+}
+
+type updatePostInput struct {
+	Title      *string
+	Body       *string
+	Status     *string
+	PublishAt  *graphql.Time
+	Visibility *string
+}
+
+func (*resolver) CreatePost(ctx context.Context, args struct{ Input createPostInput }) (*postResolver, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return nil, err
+	}
+
+	post, err := r.svc.CreatePost(r.viewer, service.PostInput{
+		Title:      args.Input.Title,
+		Body:       deref(args.Input.Body),
+		Status:     deref(args.Input.Status),
+		PublishAt:  timePtr(args.Input.PublishAt),
+		Visibility: deref(args.Input.Visibility),
+	})
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &postResolver{r: r, post: post}, nil
+}
+
+func (*resolver) UpdatePost(ctx context.Context, args struct {
+	ID      graphql.ID
+	Input   updatePostInput
+	IfMatch *string
+}) (*postResolver, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := r.svc.UpdatePost(r.viewer, id, service.PostUpdate{
+		Title:      args.Input.Title,
+		Body:       args.Input.Body,
+		Status:     deref(args.Input.Status),
+		PublishAt:  timePtr(args.Input.PublishAt),
+		Visibility: deref(args.Input.Visibility),
+	}, deref(args.IfMatch))
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	r.posts.forget(id)
+	return &postResolver{r: r, post: post}, nil
+}
+
+func (*resolver) DeletePost(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return false, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.svc.DeletePost(r.viewer, id); err != nil {
+		return false, toGraphQLError(err)
+	}
+	r.posts.forget(id)
+	return true, nil
+}
+
+func (*resolver) LikePost(ctx context.Context, args struct{ PostID graphql.ID }) (*postResolver, error) {
+	return setLike(ctx, args.PostID, true)
+}
+
+func (*resolver) UnlikePost(ctx context.Context, args struct{ PostID graphql.ID }) (*postResolver, error) {
+	return setLike(ctx, args.PostID, false)
+}
+
+func setLike(ctx context.Context, postID graphql.ID, liked bool) (*postResolver, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.svc.SetLike(r.viewer, id, liked); err != nil {
+		return nil, toGraphQLError(err)
+	}
+	r.liked.forget(id)
+	r.posts.forget(id)
+
+	post, err := r.svc.GetPost(r.viewer, id)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	return &postResolver{r: r, post: post}, nil
+}
+
+func (*resolver) CreateComment(ctx context.Context, args struct {
+	PostID graphql.ID
+	Body   string
+}) (*commentResolver, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return nil, err
+	}
+
+	postID, err := parseID(args.PostID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := r.svc.CreateComment(r.viewer, postID, args.Body)
+	if err != nil {
+		return nil, toGraphQLError(err)
+	}
+	r.posts.forget(postID)
+	return &commentResolver{r: r, comment: comment}, nil
+}
+
+func (*resolver) DeleteComment(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	r := requestFrom(ctx)
+	if err := r.checkWrite(); err != nil {
+		return false, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.svc.DeleteComment(r.viewer, id); err != nil {
+		return false, toGraphQLError(err)
+	}
+	return true, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
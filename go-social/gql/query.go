@@ -0,0 +1,90 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// resolver is the root of both the query and the mutation type. Request
+// state comes from the context, since one resolver serves every request.
+type resolver struct{}
+
+func (*resolver) Me(ctx context.Context) (*userResolver, error) {
+	r := requestFrom(ctx)
+	if r.viewer.ID == 0 {
+		return nil, nil
+	}
+	return r.user(r.viewer.ID)
+}
+
+func (*resolver) User(ctx context.Context, args struct {
+	ID     *graphql.ID
+	Handle *string
+}) (*userResolver, error) {
+	r := requestFrom(ctx)
+
+	switch {
+	case args.ID != nil && args.Handle == nil:
+		id, err := parseID(*args.ID)
+		if err != nil {
+			return nil, err
+		}
+		return r.user(id)
+	case args.Handle != nil && args.ID == nil:
+		handle, err := utils.NormalizeHandle(*args.Handle)
+		if err != nil {
+			return nil, nil
+		}
+		var user models.User
+		if err := r.db.Where("handle = ?", handle).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, internalError()
+		}
+		return &userResolver{r: r, user: user}, nil
+	default:
+		return nil, badInput("pass exactly one of id and handle")
+	}
+}
+
+func (*resolver) Post(ctx context.Context, args struct{ ID graphql.ID }) (*postResolver, error) {
+	r := requestFrom(ctx)
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := r.svc.GetPost(r.viewer, id)
+	if err != nil {
+		if service.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, toGraphQLError(err)
+	}
+	return &postResolver{r: r, post: post}, nil
+}
+
+func (*resolver) Feed(ctx context.Context, args connectionArgs) (*postConnection, error) {
+	r := requestFrom(ctx)
+	return r.postConnection(args, service.Feed(r.viewer)...)
+}
+
+// user loads a user through the batch loader; a missing user is null.
+func (r *request) user(id uint) (*userResolver, error) {
+	user, ok, err := r.users.load(id)
+	if err != nil {
+		return nil, internalError()
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &userResolver{r: r, user: user}, nil
+}
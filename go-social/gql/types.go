@@ -0,0 +1,175 @@
+package gql
+
+import (
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+)
+
+type userResolver struct {
+	r    *request
+	user models.User
+}
+
+func (u *userResolver) ID() graphql.ID    { return marshalID(u.user.ID) }
+func (u *userResolver) Name() string      { return u.user.Name }
+func (u *userResolver) Handle() *string   { return u.user.Handle }
+func (u *userResolver) AvatarURL() string { return u.user.AvatarURL }
+
+func (u *userResolver) Private() bool {
+	return !u.r.viewer.CanSeeActivity(u.user)
+}
+
+func (u *userResolver) Bio() *string {
+	if u.Private() {
+		return nil
+	}
+	return &u.user.Bio
+}
+
+func (u *userResolver) JoinedAt() *graphql.Time {
+	if u.Private() {
+		return nil
+	}
+	return &graphql.Time{Time: u.user.CreatedAt}
+}
+
+func (u *userResolver) Email() *string {
+	if !u.r.viewer.CanSeePrivate(u.user) {
+		return nil
+	}
+	return &u.user.Email
+}
+
+func (u *userResolver) Posts(args connectionArgs) (*postConnection, error) {
+	if u.Private() {
+		return nil, forbidden("this account is private")
+	}
+	return u.r.postConnection(args, service.ByAuthor(u.r.viewer, u.user.ID)...)
+}
+
+func (u *userResolver) Likes(args connectionArgs) (*likeConnection, error) {
+	if u.Private() {
+		return nil, forbidden("this account is private")
+	}
+	if !u.r.viewer.CanSeeLikes(u.user) {
+		return nil, forbidden("this user's likes are private")
+	}
+	return u.r.likeConnection(u.user.ID, args)
+}
+
+type postResolver struct {
+	r    *request
+	post models.Post
+}
+
+func (p *postResolver) ID() graphql.ID             { return marshalID(p.post.ID) }
+func (p *postResolver) Title() string              { return p.post.Title }
+func (p *postResolver) Body() string               { return p.post.Body }
+func (p *postResolver) BodyHTML() string           { return p.post.BodyHTML }
+func (p *postResolver) Status() string             { return p.post.Status }
+func (p *postResolver) Visibility() string         { return p.post.Visibility }
+func (p *postResolver) PublishAt() *graphql.Time   { return graphQLTime(p.post.PublishAt) }
+func (p *postResolver) PublishedAt() *graphql.Time { return graphQLTime(p.post.PublishedAt) }
+func (p *postResolver) CreatedAt() graphql.Time    { return graphql.Time{Time: p.post.CreatedAt} }
+func (p *postResolver) UpdatedAt() graphql.Time    { return graphql.Time{Time: p.post.UpdatedAt} }
+func (p *postResolver) LikesCount() int32          { return int32(p.post.LikesCount) }
+func (p *postResolver) CommentsCount() int32       { return int32(p.post.CommentsCount) }
+
+func (p *postResolver) Author() (*userResolver, error) {
+	return p.r.user(p.post.UserID)
+}
+
+func (p *postResolver) ViewerHasLiked() (bool, error) {
+	if p.r.viewer.ID == 0 {
+		return false, nil
+	}
+	liked, _, err := p.r.liked.load(p.post.ID)
+	if err != nil {
+		return false, internalError()
+	}
+	return liked, nil
+}
+
+func (p *postResolver) ViewerHasBookmarked() (bool, error) {
+	if p.r.viewer.ID == 0 {
+		return false, nil
+	}
+	bookmarked, _, err := p.r.bookmarked.load(p.post.ID)
+	if err != nil {
+		return false, internalError()
+	}
+	return bookmarked, nil
+}
+
+func (p *postResolver) Comments(args connectionArgs) (*commentConnection, error) {
+	return p.r.commentConnection(p.post.ID, args)
+}
+
+type commentResolver struct {
+	r       *request
+	comment models.Comment
+}
+
+func (c *commentResolver) ID() graphql.ID          { return marshalID(c.comment.ID) }
+func (c *commentResolver) Body() string            { return c.comment.Body }
+func (c *commentResolver) BodyHTML() string        { return c.comment.BodyHTML }
+func (c *commentResolver) CreatedAt() graphql.Time { return graphql.Time{Time: c.comment.CreatedAt} }
+func (c *commentResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: c.comment.UpdatedAt} }
+
+func (c *commentResolver) Author() (*userResolver, error) {
+	return c.r.user(c.comment.UserID)
+}
+
+func (c *commentResolver) Post() (*postResolver, error) {
+	return c.r.post(c.comment.PostID)
+}
+
+type likeResolver struct {
+	r    *request
+	like models.Like
+}
+
+func (l *likeResolver) CreatedAt() graphql.Time { return graphql.Time{Time: l.like.CreatedAt} }
+
+func (l *likeResolver) User() (*userResolver, error) {
+	return l.r.user(l.like.UserID)
+}
+
+func (l *likeResolver) Post() (*postResolver, error) {
+	post, err := l.r.post(l.like.PostID)
+	if err == nil && post == nil {
+		// The connection only lists likes on posts the caller can see
+		err = internalError()
+	}
+	return post, err
+}
+
+// post loads a post the caller can see through the batch loader; anything
+// else is null.
+func (r *request) post(id uint) (*postResolver, error) {
+	post, ok, err := r.posts.load(id)
+	if err != nil {
+		return nil, internalError()
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &postResolver{r: r, post: post}, nil
+}
+
+func graphQLTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
+
+func timePtr(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
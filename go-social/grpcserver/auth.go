@@ -0,0 +1,86 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// authenticator identifies the caller of every RPC, like OptionalJWTAuth
+// followed by RejectSuspended: anonymous calls pass through as the zero
+// Viewer and each RPC decides whether it needs a user, but a token that is
+// present and invalid is rejected.
+type authenticator struct {
+	db        *gorm.DB
+	jwtSecret string
+}
+
+type viewerKey struct{}
+
+func viewerFrom(ctx context.Context) service.Viewer {
+	v, _ := ctx.Value(viewerKey{}).(service.Viewer)
+	return v
+}
+
+func (a *authenticator) unary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authenticator) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return context.WithValue(ctx, viewerKey{}, service.Viewer{}), nil
+	}
+
+	tokenString, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || tokenString == "" {
+		return nil, status.Error(codes.Unauthenticated, "bearer token required")
+	}
+	claims, err := utils.ParseToken(tokenString, a.jwtSecret)
+	if err != nil || claims.UserID == 0 {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+
+	var user models.User
+	if err := a.db.Select("id, role, suspended_until").First(&user, claims.UserID).Error; err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	if user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()) {
+		return nil, status.Errorf(codes.PermissionDenied, "account suspended until %s", user.SuspendedUntil.Format(time.RFC3339))
+	}
+
+	return context.WithValue(ctx, viewerKey{}, service.NewViewer(a.db, user.ID, user.Role)), nil
+}
+
+// authenticatedStream hands the context carrying the viewer to stream
+// handlers.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
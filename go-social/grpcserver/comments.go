@@ -0,0 +1,59 @@
+package grpcserver
+
+import (
+	"context"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"github.com/krisn2/go-social/service"
+)
+
+type commentServer struct {
+	socialv1.UnimplementedCommentServiceServer
+	*server
+}
+
+func (s *commentServer) ListComments(ctx context.Context, req *socialv1.ListCommentsRequest) (*socialv1.ListCommentsResponse, error) {
+	v := viewerFrom(ctx)
+	if _, err := s.svc.GetPost(v, uint(req.PostId)); err != nil {
+		return nil, toStatus(err)
+	}
+
+	comments, more, err := s.svc.CommentPage(v, uint(req.PostId), pageSize(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var userIDs []uint
+	for _, comment := range comments {
+		userIDs = append(userIDs, comment.UserID)
+	}
+	users, err := s.svc.UsersByID(userIDs)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &socialv1.ListCommentsResponse{Comments: make([]*socialv1.Comment, 0, len(comments))}
+	for _, comment := range comments {
+		resp.Comments = append(resp.Comments, toComment(v, comment, users[comment.UserID]))
+	}
+	if more {
+		resp.NextPageToken = service.CommentCursor(comments[len(comments)-1])
+	}
+	return resp, nil
+}
+
+func (s *commentServer) CreateComment(ctx context.Context, req *socialv1.CreateCommentRequest) (*socialv1.Comment, error) {
+	v := viewerFrom(ctx)
+	comment, err := s.svc.CreateComment(v, uint(req.PostId), req.Body)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toComment(v, comment, comment.User), nil
+}
+
+func (s *commentServer) DeleteComment(ctx context.Context, req *socialv1.DeleteCommentRequest) (*socialv1.DeleteCommentResponse, error) {
+	if err := s.svc.DeleteComment(viewerFrom(ctx), uint(req.Id)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &socialv1.DeleteCommentResponse{}, nil
+}
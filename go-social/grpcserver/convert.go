@@ -0,0 +1,116 @@
+package grpcserver
+
+import (
+	"time"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// toUser withholds the same fields as the GraphQL User type.
+func toUser(v service.Viewer, user models.User) *socialv1.User {
+	pb := &socialv1.User{
+		Id:        uint32(user.ID),
+		Name:      user.Name,
+		Handle:    user.Handle,
+		AvatarUrl: user.AvatarURL,
+		Private:   !v.CanSeeActivity(user),
+	}
+	if !pb.Private {
+		pb.Bio = user.Bio
+		pb.JoinedAt = timestamppb.New(user.CreatedAt)
+	}
+	if v.CanSeePrivate(user) {
+		pb.Email = user.Email
+	}
+	return pb
+}
+
+// toPosts converts a page of posts, loading authors, like and bookmark state
+// in one query each.
+func (s *server) toPosts(v service.Viewer, posts []models.Post) ([]*socialv1.Post, error) {
+	var postIDs, userIDs []uint
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		userIDs = append(userIDs, post.UserID)
+	}
+
+	users, err := s.svc.UsersByID(userIDs)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	liked := map[uint]bool{}
+	bookmarked := map[uint]bool{}
+	if v.ID != 0 {
+		liked = service.LikedSet(s.db, v.ID, postIDs)
+		bookmarked = service.BookmarkedSet(s.db, v.ID, postIDs)
+	}
+
+	list := make([]*socialv1.Post, 0, len(posts))
+	for _, post := range posts {
+		post.User = users[post.UserID]
+		list = append(list, newPost(v, post, liked[post.ID], bookmarked[post.ID]))
+	}
+	return list, nil
+}
+
+// toPost converts a single post whose author is already loaded.
+func (s *server) toPost(v service.Viewer, post models.Post) *socialv1.Post {
+	var liked, bookmarked bool
+	if v.ID != 0 {
+		liked = service.LikedSet(s.db, v.ID, []uint{post.ID})[post.ID]
+		bookmarked = service.BookmarkedSet(s.db, v.ID, []uint{post.ID})[post.ID]
+	}
+	return newPost(v, post, liked, bookmarked)
+}
+
+func newPost(v service.Viewer, post models.Post, liked, bookmarked bool) *socialv1.Post {
+	return &socialv1.Post{
+		Id:                  uint32(post.ID),
+		Title:               post.Title,
+		Body:                post.Body,
+		BodyHtml:            post.BodyHTML,
+		Status:              post.Status,
+		Visibility:          post.Visibility,
+		PublishAt:           timestamp(post.PublishAt),
+		PublishedAt:         timestamp(post.PublishedAt),
+		CreatedAt:           timestamppb.New(post.CreatedAt),
+		UpdatedAt:           timestamppb.New(post.UpdatedAt),
+		Author:              toUser(v, post.User),
+		LikesCount:          int32(post.LikesCount),
+		CommentsCount:       int32(post.CommentsCount),
+		ViewerHasLiked:      liked,
+		ViewerHasBookmarked: bookmarked,
+		Etag:                utils.PostETag(post, v.ID),
+	}
+}
+
+func toComment(v service.Viewer, comment models.Comment, author models.User) *socialv1.Comment {
+	return &socialv1.Comment{
+		Id:        uint32(comment.ID),
+		PostId:    uint32(comment.PostID),
+		Body:      comment.Body,
+		BodyHtml:  comment.BodyHTML,
+		Author:    toUser(v, author),
+		CreatedAt: timestamppb.New(comment.CreatedAt),
+		UpdatedAt: timestamppb.New(comment.UpdatedAt),
+	}
+}
@@ -0,0 +1,29 @@
+package grpcserver
+
+import (
+	"errors"
+
+	"github.com/krisn2/go-social/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var statusCodes = map[service.Code]codes.Code{
+	service.CodeInvalid:            codes.InvalidArgument,
+	service.CodeUnauthenticated:    codes.Unauthenticated,
+	service.CodeForbidden:          codes.PermissionDenied,
+	service.CodeNotFound:           codes.NotFound,
+	service.CodeConflict:           codes.AlreadyExists,
+	service.CodePreconditionFailed: codes.Aborted,
+	service.CodeInternal:           codes.Internal,
+}
+
+// toStatus keeps the caller-safe message of a service error and hides
+// anything else, such as database errors.
+func toStatus(err error) error {
+	var e *service.Error
+	if !errors.As(err, &e) {
+		return status.Error(codes.Internal, "internal server error")
+	}
+	return status.Error(statusCodes[e.Code], e.Message)
+}
@@ -0,0 +1,93 @@
+package grpcserver
+
+import (
+	"context"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type likeServer struct {
+	socialv1.UnimplementedLikeServiceServer
+	*server
+}
+
+func (s *likeServer) LikePost(ctx context.Context, req *socialv1.LikePostRequest) (*socialv1.Post, error) {
+	return s.setLike(ctx, req.PostId, true)
+}
+
+func (s *likeServer) UnlikePost(ctx context.Context, req *socialv1.UnlikePostRequest) (*socialv1.Post, error) {
+	return s.setLike(ctx, req.PostId, false)
+}
+
+func (s *likeServer) setLike(ctx context.Context, postID uint32, liked bool) (*socialv1.Post, error) {
+	v := viewerFrom(ctx)
+	if err := s.svc.SetLike(v, uint(postID), liked); err != nil {
+		return nil, toStatus(err)
+	}
+
+	post, err := s.svc.GetPost(v, uint(postID))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toPost(v, post), nil
+}
+
+func (s *likeServer) ListLikes(ctx context.Context, req *socialv1.ListLikesRequest) (*socialv1.ListLikesResponse, error) {
+	v := viewerFrom(ctx)
+	user, err := s.svc.GetUser(uintString(req.UserId))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if !v.CanSeeActivity(user) {
+		return nil, status.Error(codes.PermissionDenied, "this account is private")
+	}
+	if !v.CanSeeLikes(user) {
+		return nil, status.Error(codes.PermissionDenied, "this user's likes are private")
+	}
+
+	likes, more, err := s.svc.LikePage(v, user.ID, pageSize(req.PageSize), req.PageToken)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	var postIDs []uint
+	for _, like := range likes {
+		postIDs = append(postIDs, like.PostID)
+	}
+	byID, err := s.svc.PostsByID(v, postIDs)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	posts := make([]models.Post, 0, len(byID))
+	for _, id := range postIDs {
+		if post, ok := byID[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+	converted, err := s.toPosts(v, posts)
+	if err != nil {
+		return nil, err
+	}
+	postPBs := make(map[uint32]*socialv1.Post, len(converted))
+	for _, post := range converted {
+		postPBs[post.Id] = post
+	}
+
+	resp := &socialv1.ListLikesResponse{Likes: make([]*socialv1.Like, 0, len(likes))}
+	for _, like := range likes {
+		resp.Likes = append(resp.Likes, &socialv1.Like{
+			UserId:    uint32(like.UserID),
+			Post:      postPBs[uint32(like.PostID)],
+			CreatedAt: timestamppb.New(like.CreatedAt),
+		})
+	}
+	if more {
+		resp.NextPageToken = service.LikeCursor(likes[len(likes)-1])
+	}
+	return resp, nil
+}
@@ -0,0 +1,180 @@
+package grpcserver
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// commitLag is how far back each StreamPosts poll looks again, so posts
+// from transactions that commit after a poll has passed their timestamp are
+// still picked up.
+const commitLag = 5 * time.Second
+
+type postServer struct {
+	socialv1.UnimplementedPostServiceServer
+	*server
+}
+
+func (s *postServer) GetPost(ctx context.Context, req *socialv1.GetPostRequest) (*socialv1.Post, error) {
+	v := viewerFrom(ctx)
+	post, err := s.svc.GetPost(v, uint(req.Id))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toPost(v, post), nil
+}
+
+func (s *postServer) ListPosts(ctx context.Context, req *socialv1.ListPostsRequest) (*socialv1.ListPostsResponse, error) {
+	v := viewerFrom(ctx)
+
+	scopes := service.Feed(v)
+	if req.AuthorId != 0 {
+		author, err := s.svc.GetUser(uintString(req.AuthorId))
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		if !v.CanSeeActivity(author) {
+			return nil, status.Error(codes.PermissionDenied, "this account is private")
+		}
+		scopes = service.ByAuthor(v, author.ID)
+	}
+
+	posts, more, err := s.svc.PostPage(v, pageSize(req.PageSize), req.PageToken, scopes...)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	list, err := s.toPosts(v, posts)
+	if err != nil {
+		return nil, err
+	}
+	resp := &socialv1.ListPostsResponse{Posts: list}
+	if more {
+		resp.NextPageToken = service.PostCursor(posts[len(posts)-1])
+	}
+	return resp, nil
+}
+
+func (s *postServer) CreatePost(ctx context.Context, req *socialv1.CreatePostRequest) (*socialv1.Post, error) {
+	v := viewerFrom(ctx)
+	post, err := s.svc.CreatePost(v, service.PostInput{
+		Title:      req.Title,
+		Body:       req.Body,
+		Status:     req.Status,
+		PublishAt:  timePtr(req.PublishAt),
+		Visibility: req.Visibility,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toPost(v, post), nil
+}
+
+func (s *postServer) UpdatePost(ctx context.Context, req *socialv1.UpdatePostRequest) (*socialv1.Post, error) {
+	v := viewerFrom(ctx)
+	post, err := s.svc.UpdatePost(v, uint(req.Id), service.PostUpdate{
+		Title:      req.Title,
+		Body:       req.Body,
+		Status:     req.Status,
+		PublishAt:  timePtr(req.PublishAt),
+		Visibility: req.Visibility,
+	}, req.IfMatch)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return s.toPost(v, post), nil
+}
+
+func (s *postServer) DeletePost(ctx context.Context, req *socialv1.DeletePostRequest) (*socialv1.DeletePostResponse, error) {
+	if err := s.svc.DeletePost(viewerFrom(ctx), uint(req.Id)); err != nil {
+		return nil, toStatus(err)
+	}
+	return &socialv1.DeletePostResponse{}, nil
+}
+
+// StreamPosts polls for posts that join the caller's timeline after the
+// stream opens. Posts are found by live_at rather than published_at, because
+// scheduled posts go live with their original publish_at, and polled by when
+// they last changed. Each version of a post is sent once, so edits made
+// while the stream is open are sent again.
+func (s *postServer) StreamPosts(_ *socialv1.StreamPostsRequest, stream socialv1.PostService_StreamPostsServer) error {
+	ctx := stream.Context()
+	v := viewerFrom(ctx)
+
+	opened := time.Now()
+	since, afterID := opened, uint(0)
+	sent := map[uint]time.Time{} // post ID to the updated_at last sent
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		polled := time.Now()
+		var posts []models.Post
+		err := s.svc.Posts(v, service.Feed(v)...).
+			Where("(posts.updated_at, posts.id) > (?, ?) AND posts.live_at >= ?", since, afterID, opened).
+			Order("posts.updated_at ASC, posts.id ASC").
+			Limit(service.MaxPageSize).
+			Find(&posts).Error
+		if err != nil {
+			return status.Error(codes.Internal, "failed to fetch posts")
+		}
+
+		// A full batch resumes right after its last post, so posts sharing
+		// one updated_at cannot hold the cursor in place; otherwise the next
+		// poll looks back commitLag before this one
+		if len(posts) == service.MaxPageSize {
+			last := posts[len(posts)-1]
+			since, afterID = last.UpdatedAt, last.ID
+		} else {
+			since, afterID = polled.Add(-commitLag), 0
+		}
+
+		// Posts last changed before since are not returned again until they
+		// change, so they no longer need remembering
+		for id, updated := range sent {
+			if updated.Before(since) {
+				delete(sent, id)
+			}
+		}
+
+		posts = unsent(posts, sent)
+		list, err := s.toPosts(v, posts)
+		if err != nil {
+			return err
+		}
+		for i, post := range list {
+			if err := stream.Send(post); err != nil {
+				return err
+			}
+			sent[posts[i].ID] = posts[i].UpdatedAt
+		}
+	}
+}
+
+func unsent(posts []models.Post, sent map[uint]time.Time) []models.Post {
+	var fresh []models.Post
+	for _, post := range posts {
+		if updated, ok := sent[post.ID]; !ok || !updated.Equal(post.UpdatedAt) {
+			fresh = append(fresh, post)
+		}
+	}
+	return fresh
+}
+
+func uintString(id uint32) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,50 @@
+// Package grpcserver serves the gRPC API defined in api/social/v1. It runs
+// next to the REST and GraphQL endpoints and goes through the same service
+// layer, so all three enforce the same rules.
+package grpcserver
+
+import (
+	"time"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"github.com/krisn2/go-social/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/gorm"
+)
+
+const defaultPageSize = 20
+
+type server struct {
+	db  *gorm.DB
+	svc *service.Service
+	// how often StreamPosts looks for new posts
+	pollInterval time.Duration
+}
+
+// New returns a gRPC server with every service registered. Callers are
+// authenticated from the "authorization: Bearer <token>" metadata using
+// the same JWTs as the REST API.
+func New(db *gorm.DB, jwtSecret string, pollInterval time.Duration) *grpc.Server {
+	auth := &authenticator{db: db, jwtSecret: jwtSecret}
+	gs := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.unary),
+		grpc.ChainStreamInterceptor(auth.stream),
+	)
+
+	s := &server{db: db, svc: service.New(db), pollInterval: pollInterval}
+	socialv1.RegisterUserServiceServer(gs, &userServer{server: s})
+	socialv1.RegisterPostServiceServer(gs, &postServer{server: s})
+	socialv1.RegisterCommentServiceServer(gs, &commentServer{server: s})
+	socialv1.RegisterLikeServiceServer(gs, &likeServer{server: s})
+	reflection.Register(gs)
+	return gs
+}
+
+// pageSize applies the default page size; the service checks the maximum.
+func pageSize(n int32) int {
+	if n == 0 {
+		return defaultPageSize
+	}
+	return int(n)
+}
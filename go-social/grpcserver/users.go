@@ -0,0 +1,46 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	socialv1 "github.com/krisn2/go-social/api/social/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userServer struct {
+	socialv1.UnimplementedUserServiceServer
+	*server
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *socialv1.GetUserRequest) (*socialv1.User, error) {
+	var ref string
+	switch u := req.User.(type) {
+	case *socialv1.GetUserRequest_Id:
+		ref = uintString(u.Id)
+	case *socialv1.GetUserRequest_Handle:
+		ref = "@" + strings.TrimPrefix(u.Handle, "@")
+	default:
+		return nil, status.Error(codes.InvalidArgument, "id or handle is required")
+	}
+
+	user, err := s.svc.GetUser(ref)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(viewerFrom(ctx), user), nil
+}
+
+func (s *userServer) GetMe(ctx context.Context, _ *socialv1.GetMeRequest) (*socialv1.User, error) {
+	v := viewerFrom(ctx)
+	if v.ID == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	user, err := s.svc.GetUser(uintString(uint32(v.ID)))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(v, user), nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+type AuditHandler struct {
+	db    *gorm.DB
+	audit *audit.Logger
+}
+
+func NewAuditHandler(db *gorm.DB, auditLog *audit.Logger) *AuditHandler {
+	return &AuditHandler{db: db, audit: auditLog}
+}
+
+// RequireAdmin stops non-admins before they reach the admin routes. It must
+// run after JWTAuth.
+func (h *AuditHandler) RequireAdmin(c *gin.Context) {
+	if !currentViewer(h.db, c).IsAdmin() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	c.Next()
+}
+
+// Mine lists events the current user performed or that were aimed at their
+// account, such as failed logins, newest first.
+func (h *AuditHandler) Mine(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	query := h.db.Model(&models.AuditEvent{}).
+		Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, models.TargetUser, userID)
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	h.list(c, query)
+}
+
+// Query searches the whole audit log. Filters: actor_id, target_type,
+// target_id, action (comma-separated, a trailing "." matches a prefix such
+// as "auth."), ip, since and until (RFC 3339).
+func (h *AuditHandler) Query(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+
+	query := h.db.Model(&models.AuditEvent{})
+	for _, filter := range []string{"actor_id", "target_id"} {
+		if v := c.Query(filter); v != "" {
+			id, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + filter})
+				return
+			}
+			query = query.Where(filter+" = ?", id)
+		}
+	}
+	for _, filter := range []string{"target_type", "ip"} {
+		if v := c.Query(filter); v != "" {
+			query = query.Where(filter+" = ?", v)
+		}
+	}
+	if v := c.Query("action"); v != "" {
+		var conds []string
+		var args []interface{}
+		for _, action := range strings.Split(v, ",") {
+			action = strings.TrimSpace(action)
+			if strings.HasSuffix(action, ".") {
+				conds = append(conds, "action LIKE ?")
+				args = append(args, action+"%")
+			} else {
+				conds = append(conds, "action = ?")
+				args = append(args, action)
+			}
+		}
+		query = query.Where(strings.Join(conds, " OR "), args...)
+	}
+	for filter, op := range map[string]string{"since": ">=", "until": "<"} {
+		if v := c.Query(filter); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": filter + " must be an RFC 3339 timestamp"})
+				return
+			}
+			query = query.Where("created_at "+op+" ?", t)
+		}
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &adminID, Action: models.AuditSecurityEventsQuery, Details: c.Request.URL.RawQuery})
+
+	h.list(c, query)
+}
+
+func (h *AuditHandler) list(c *gin.Context, query *gorm.DB) {
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.AuditEvent
+	if err := query.Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch security events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      events,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
 	"github.com/krisn2/go-social/config"
 	"github.com/krisn2/go-social/models"
 	"github.com/krisn2/go-social/utils"
@@ -12,12 +14,13 @@ import (
 )
 
 type AuthHandler struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db    *gorm.DB
+	cfg   *config.Config
+	audit *audit.Logger
 }
 
-func NewAuthHandler(db *gorm.DB, cfg *config.Config) *AuthHandler {
-	return &AuthHandler{db: db, cfg: cfg}
+func NewAuthHandler(db *gorm.DB, cfg *config.Config, auditLog *audit.Logger) *AuthHandler {
+	return &AuthHandler{db: db, cfg: cfg, audit: auditLog}
 }
 
 type RegisterRequest struct {
@@ -50,6 +53,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	h.audit.Record(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditRegister})
+
 	token, err := utils.GenerateToken(user, h.cfg.JWTSecret)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
@@ -58,7 +63,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, gin.H{
 		"token": token,
-		"user":  utils.UserResponse(*user),
+		"user":  utils.PrivateUserResponse(*user),
 	})
 }
 
@@ -74,25 +79,40 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	email := strings.ToLower(req.Email)
+
 	var user models.User
-	if err := h.db.Where("email = ?", strings.ToLower(req.Email)).First(&user).Error; err != nil {
+	if err := h.db.Where("email = ?", email).First(&user).Error; err != nil {
+		h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, Details: "unknown email " + email})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
 	if err := utils.CheckPassword(user.Password, req.Password); err != nil {
+		h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, TargetType: models.TargetUser, TargetID: &user.ID, Details: "wrong password"})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
 
+	if user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()) {
+		h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, TargetType: models.TargetUser, TargetID: &user.ID, Details: "account suspended"})
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":           "account suspended",
+			"suspended_until": user.SuspendedUntil,
+		})
+		return
+	}
+
 	token, err := utils.GenerateToken(&user, h.cfg.JWTSecret)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
+	h.audit.Record(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditLogin})
+
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
-		"user":  utils.UserResponse(user),
+		"user":  utils.PrivateUserResponse(user),
 	})
 }
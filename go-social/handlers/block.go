@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockHandler struct {
+	db *gorm.DB
+}
+
+func NewBlockHandler(db *gorm.DB) *BlockHandler {
+	return &BlockHandler{db: db}
+}
+
+func (h *BlockHandler) Block(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, ok := h.findTarget(c, userID)
+	if !ok {
+		return
+	}
+
+	block := models.Block{BlockerID: userID, BlockedID: target.ID}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// A block ends any follow relationship in both directions
+		if err := tx.Where("(follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)",
+			userID, target.ID, target.ID, userID).Delete(&models.Follow{}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to block user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked": true})
+}
+
+func (h *BlockHandler) Unblock(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, ok := h.findTarget(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Where("blocker_id = ? AND blocked_id = ?", userID, target.ID).Delete(&models.Block{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unblock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked": false})
+}
+
+func (h *BlockHandler) ListBlocks(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.Block{}).Where("blocker_id = ?", userID).Count(&total)
+
+	var blocks []models.Block
+	if err := h.db.Preload("Blocked").
+		Where("blocker_id = ?", userID).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch blocks"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(blocks))
+	for _, block := range blocks {
+		response = append(response, gin.H{
+			"user":       utils.UserResponse(block.Blocked),
+			"blocked_at": block.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+func (h *BlockHandler) Mute(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, ok := h.findTarget(c, userID)
+	if !ok {
+		return
+	}
+
+	mute := models.Mute{MuterID: userID, MutedID: target.ID}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mute user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"muted": true})
+}
+
+func (h *BlockHandler) Unmute(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, ok := h.findTarget(c, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Where("muter_id = ? AND muted_id = ?", userID, target.ID).Delete(&models.Mute{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unmute user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"muted": false})
+}
+
+func (h *BlockHandler) ListMutes(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.Mute{}).Where("muter_id = ?", userID).Count(&total)
+
+	var mutes []models.Mute
+	if err := h.db.Preload("Muted").
+		Where("muter_id = ?", userID).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&mutes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch mutes"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(mutes))
+	for _, mute := range mutes {
+		response = append(response, gin.H{
+			"user":     utils.UserResponse(mute.Muted),
+			"muted_at": mute.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// findTarget resolves the user in the route and rejects acting on oneself.
+func (h *BlockHandler) findTarget(c *gin.Context, userID uint) (models.User, bool) {
+	target, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return target, false
+	}
+
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot block or mute yourself"})
+		return target, false
+	}
+
+	return target, true
+}
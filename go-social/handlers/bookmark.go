@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkHandler struct {
+	db *gorm.DB
+}
+
+func NewBookmarkHandler(db *gorm.DB) *BookmarkHandler {
+	return &BookmarkHandler{db: db}
+}
+
+type BookmarkRequest struct {
+	CollectionID *uint `json:"collection_id"`
+}
+
+// Add bookmarks a post, or moves an existing bookmark to another collection.
+func (h *BookmarkHandler) Add(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	post, err := service.FindVisiblePost(h.db, currentViewer(h.db, c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+
+	// The body is optional
+	var req BookmarkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.CollectionID != nil {
+		var count int64
+		h.db.Model(&models.BookmarkCollection{}).Where("id = ? AND user_id = ?", *req.CollectionID, userID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+			return
+		}
+	}
+
+	bookmark := models.Bookmark{UserID: userID, PostID: post.ID, CollectionID: req.CollectionID}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(&bookmark).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to bookmark post"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookmarked": true, "collection_id": req.CollectionID})
+}
+
+func (h *BookmarkHandler) Remove(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	if err := h.db.Where("user_id = ? AND post_id = ?", userID, c.Param("id")).Delete(&models.Bookmark{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove bookmark"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookmarked": false})
+}
+
+func (h *BookmarkHandler) ListCollections(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var collections []struct {
+		models.BookmarkCollection
+		BookmarksCount int64 `json:"bookmarks"`
+	}
+	if err := h.db.Model(&models.BookmarkCollection{}).
+		Select(`bookmark_collections.*,
+			(SELECT COUNT(*) FROM bookmarks WHERE bookmarks.collection_id = bookmark_collections.id) AS bookmarks_count`).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Scan(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collections})
+}
+
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=100"`
+}
+
+func (h *BookmarkHandler) CreateCollection(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := models.BookmarkCollection{UserID: userID, Name: strings.TrimSpace(req.Name)}
+	if err := h.db.Create(&collection).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "collection name already in use"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+func (h *BookmarkHandler) RenameCollection(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var collection models.BookmarkCollection
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&collection).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		return
+	}
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.Model(&collection).Update("name", strings.TrimSpace(req.Name)).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "collection name already in use"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection removes a collection. Its bookmarks are kept, uncollected.
+func (h *BookmarkHandler) DeleteCollection(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var collection models.BookmarkCollection
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&collection).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
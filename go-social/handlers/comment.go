@@ -2,20 +2,22 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/krisn2/go-social/middleware"
 	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
 	"github.com/krisn2/go-social/utils"
 	"gorm.io/gorm"
 )
 
 type CommentHandler struct {
-	db *gorm.DB
+	db  *gorm.DB
+	svc *service.Service
 }
 
 func NewCommentHandler(db *gorm.DB) *CommentHandler {
-	return &CommentHandler{db: db}
+	return &CommentHandler{db: db, svc: service.New(db)}
 }
 
 type CommentRequest struct {
@@ -23,11 +25,8 @@ type CommentRequest struct {
 }
 
 func (h *CommentHandler) Create(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
-
-	var post models.Post
-	if err := h.db.First(&post, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
 		return
 	}
 
@@ -37,32 +36,29 @@ func (h *CommentHandler) Create(c *gin.Context) {
 		return
 	}
 
-	comment := &models.Comment{
-		Body:   req.Body,
-		UserID: userID,
-		PostID: post.ID,
-	}
-
-	if err := h.db.Create(comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create comment"})
+	comment, err := h.svc.CreateComment(currentViewer(h.db, c), postID, req.Body)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	if err := h.db.Preload("User").First(comment, comment.ID).Error; err != nil {
-		c.JSON(http.StatusCreated, gin.H{"id": comment.ID})
-		return
-	}
-
-	c.JSON(http.StatusCreated, utils.CommentResponse(*comment))
+	c.JSON(http.StatusCreated, utils.CommentResponse(comment))
 }
 
 func (h *CommentHandler) List(c *gin.Context) {
+	v := currentViewer(h.db, c)
+	post, err := service.FindVisiblePost(h.db, v, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+
 	page, pageSize := utils.Paginate(c)
 
 	var comments []models.Comment
-	if err := h.db.Preload("User").
-		Where("post_id = ?", c.Param("id")).
-		Order("id ASC").
+	if err := h.svc.Comments(v, post.ID).
+		Preload("User").
+		Order("comments.id ASC").
 		Limit(pageSize).
 		Offset((page - 1) * pageSize).
 		Find(&comments).Error; err != nil {
@@ -70,6 +66,14 @@ func (h *CommentHandler) List(c *gin.Context) {
 		return
 	}
 
+	etagParts := []interface{}{v.ID, post.CommentsCount, page, pageSize}
+	for _, comment := range comments {
+		etagParts = append(etagParts, comment.ID, comment.UpdatedAt.UnixMicro(), comment.User.UpdatedAt.UnixMicro())
+	}
+	if utils.NotModified(c, utils.ListETag(etagParts...), time.Time{}, v.ID != 0) {
+		return
+	}
+
 	response := make([]gin.H, 0, len(comments))
 	for _, comment := range comments {
 		response = append(response, utils.CommentResponse(comment))
@@ -82,26 +86,64 @@ func (h *CommentHandler) List(c *gin.Context) {
 	})
 }
 
-func (h *CommentHandler) Delete(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
+// ListByUser lists the comments written by the user named in the route,
+// newest first.
+func (h *CommentHandler) ListByUser(c *gin.Context) {
+	user, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if !v.CanSeeActivity(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this account is private"})
+		return
+	}
 
-	var comment models.Comment
-	if err := h.db.First(&comment, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+	page, pageSize := utils.Paginate(c)
+
+	// Leave out comments on posts the caller cannot see
+	onVisiblePosts := func(db *gorm.DB) *gorm.DB {
+		return db.Where("comments.post_id IN (?)", h.db.Model(&models.Post{}).Select("posts.id").Scopes(service.VisiblePosts(v)))
+	}
+
+	var total int64
+	h.db.Model(&models.Comment{}).Where("user_id = ?", user.ID).Scopes(onVisiblePosts, service.VisibleComments(v), service.ExcludeBlocked(v, "comments.user_id")).Count(&total)
+
+	var comments []models.Comment
+	if err := h.db.Where("user_id = ?", user.ID).
+		Scopes(onVisiblePosts, service.VisibleComments(v), service.ExcludeBlocked(v, "comments.user_id")).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch comments"})
 		return
 	}
 
-	var post models.Post
-	h.db.Select("id, user_id").First(&post, comment.PostID)
+	response := make([]gin.H, 0, len(comments))
+	for _, comment := range comments {
+		comment.User = user
+		response = append(response, utils.CommentResponse(comment))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
 
-	// Check if user is comment author or post author
-	if comment.UserID != userID && post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized to delete this comment"})
+func (h *CommentHandler) Delete(c *gin.Context) {
+	commentID, ok := paramID(c, "id", "comment not found")
+	if !ok {
 		return
 	}
 
-	if err := h.db.Delete(&comment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete comment"})
+	if err := h.svc.DeleteComment(currentViewer(h.db, c), commentID); err != nil {
+		respondError(c, err)
 		return
 	}
 
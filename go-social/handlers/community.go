@@ -0,0 +1,516 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommunityHandler struct {
+	db *gorm.DB
+}
+
+func NewCommunityHandler(db *gorm.DB) *CommunityHandler {
+	return &CommunityHandler{db: db}
+}
+
+type CreateCommunityRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Slug        string `json:"slug" binding:"required"`
+	Description string `json:"description" binding:"max=2000"`
+	Membership  string `json:"membership" binding:"omitempty,oneof=public private"`
+}
+
+type UpdateCommunityRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=2000"`
+	Membership  string  `json:"membership" binding:"omitempty,oneof=public private"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner moderator member"`
+}
+
+// List renders the community directory. Private communities are listed
+// too; only their posts and members are restricted.
+func (h *CommunityHandler) List(c *gin.Context) {
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.Community{}).Count(&total)
+
+	var communities []struct {
+		models.Community
+		MembersCount int64
+	}
+	if err := h.db.Model(&models.Community{}).
+		Select(`communities.*,
+			(SELECT COUNT(*) FROM community_members WHERE community_members.community_id = communities.id) AS members_count`).
+		Order("members_count DESC, communities.id").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Scan(&communities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch communities"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(communities))
+	for _, community := range communities {
+		response = append(response, utils.CommunityResponse(community.Community, community.MembersCount))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Create starts a community with the caller as its owner.
+func (h *CommunityHandler) Create(c *gin.Context) {
+	v := currentViewer(h.db, c)
+
+	var req CreateCommunityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slug, err := utils.NormalizeSlug(req.Slug)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	community := models.Community{
+		Name:        strings.TrimSpace(req.Name),
+		Slug:        slug,
+		Description: req.Description,
+		Membership:  req.Membership,
+		CreatedByID: v.ID,
+	}
+	if community.Membership == "" {
+		community.Membership = models.MembershipPublic
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&community).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CommunityMember{CommunityID: community.ID, UserID: v.ID, Role: models.CommunityRoleOwner}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "slug already in use"})
+		return
+	}
+
+	response := utils.CommunityResponse(community, 1)
+	response["role"] = models.CommunityRoleOwner
+	c.JSON(http.StatusCreated, response)
+}
+
+// Get renders a community with the caller's role in it and whether they
+// have asked to join.
+func (h *CommunityHandler) Get(c *gin.Context) {
+	community, ok := h.findCommunity(c)
+	if !ok {
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	var members int64
+	h.db.Model(&models.CommunityMember{}).Where("community_id = ?", community.ID).Count(&members)
+
+	response := utils.CommunityResponse(community, members)
+	if v.ID != 0 {
+		var requested int64
+		h.db.Model(&models.JoinRequest{}).Where("community_id = ? AND user_id = ?", community.ID, v.ID).Count(&requested)
+		response["role"] = service.CommunityRole(h.db, community.ID, v.ID)
+		response["requested"] = requested > 0
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Update changes a community's details. Only the owner may do so. Opening
+// a private community admits everyone waiting to join.
+func (h *CommunityHandler) Update(c *gin.Context) {
+	community, role, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+	if role != models.CommunityRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can change the community"})
+		return
+	}
+
+	var req UpdateCommunityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		community.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		community.Description = *req.Description
+	}
+	opened := req.Membership == models.MembershipPublic && community.Membership == models.MembershipPrivate
+	if req.Membership != "" {
+		community.Membership = req.Membership
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&community).Updates(map[string]interface{}{
+			"name":        community.Name,
+			"description": community.Description,
+			"membership":  community.Membership,
+		}).Error; err != nil {
+			return err
+		}
+		if !opened {
+			return nil
+		}
+		if err := tx.Exec(`INSERT INTO community_members (community_id, user_id, role, created_at)
+			SELECT community_id, user_id, ?, NOW() FROM join_requests WHERE community_id = ?
+			ON CONFLICT DO NOTHING`, models.CommunityRoleMember, community.ID).Error; err != nil {
+			return err
+		}
+		return tx.Where("community_id = ?", community.ID).Delete(&models.JoinRequest{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update community"})
+		return
+	}
+
+	var members int64
+	h.db.Model(&models.CommunityMember{}).Where("community_id = ?", community.ID).Count(&members)
+	c.JSON(http.StatusOK, utils.CommunityResponse(community, members))
+}
+
+// Join adds the caller to a public community, or files a join request for
+// a private one.
+func (h *CommunityHandler) Join(c *gin.Context) {
+	community, ok := h.findCommunity(c)
+	if !ok {
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if role := service.CommunityRole(h.db, community.ID, v.ID); role != "" {
+		c.JSON(http.StatusOK, gin.H{"role": role})
+		return
+	}
+
+	if community.Membership == models.MembershipPrivate {
+		request := models.JoinRequest{CommunityID: community.ID, UserID: v.ID}
+		if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&request).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request to join"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"requested": true})
+		return
+	}
+
+	member := models.CommunityMember{CommunityID: community.ID, UserID: v.ID, Role: models.CommunityRoleMember}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join community"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": models.CommunityRoleMember})
+}
+
+// Leave takes the caller out of a community, or withdraws their join
+// request. The owner must hand over ownership first.
+func (h *CommunityHandler) Leave(c *gin.Context) {
+	community, ok := h.findCommunity(c)
+	if !ok {
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if service.CommunityRole(h.db, community.ID, v.ID) == models.CommunityRoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transfer ownership before leaving"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("community_id = ? AND user_id = ?", community.ID, v.ID).Delete(&models.CommunityMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("community_id = ? AND user_id = ?", community.ID, v.ID).Delete(&models.JoinRequest{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to leave community"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": ""})
+}
+
+// Members lists a community's members, owner and moderators first.
+func (h *CommunityHandler) Members(c *gin.Context) {
+	community, ok := h.findCommunity(c)
+	if !ok {
+		return
+	}
+
+	if !service.CanReadCommunity(h.db, currentViewer(h.db, c), community) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this community is private"})
+		return
+	}
+
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.CommunityMember{}).Where("community_id = ?", community.ID).Count(&total)
+
+	var members []models.CommunityMember
+	if err := h.db.Preload("User").
+		Where("community_id = ?", community.ID).
+		Order("CASE role WHEN 'owner' THEN 0 WHEN 'moderator' THEN 1 ELSE 2 END, id").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch members"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(members))
+	for _, member := range members {
+		response = append(response, gin.H{
+			"user":      utils.UserResponse(member.User),
+			"role":      member.Role,
+			"joined_at": member.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// UpdateMember changes a member's role. Only the owner may do so; making
+// someone else owner steps the current owner down to moderator.
+func (h *CommunityHandler) UpdateMember(c *gin.Context) {
+	community, role, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+	if role != models.CommunityRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can change roles"})
+		return
+	}
+
+	var req MemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, ok := h.findMember(c, community.ID)
+	if !ok {
+		return
+	}
+	if member.Role == models.CommunityRoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "make another member owner instead"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Role == models.CommunityRoleOwner {
+			if err := tx.Model(&models.CommunityMember{}).
+				Where("community_id = ? AND role = ?", community.ID, models.CommunityRoleOwner).
+				Update("role", models.CommunityRoleModerator).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&member).Update("role", req.Role).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": utils.UserResponse(member.User), "role": req.Role})
+}
+
+// RemoveMember removes someone from a community. Moderators may remove
+// members; only the owner may remove moderators.
+func (h *CommunityHandler) RemoveMember(c *gin.Context) {
+	community, role, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+
+	member, ok := h.findMember(c, community.ID)
+	if !ok {
+		return
+	}
+	if member.Role == models.CommunityRoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the owner cannot be removed"})
+		return
+	}
+	if member.Role == models.CommunityRoleModerator && role != models.CommunityRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the owner can remove moderators"})
+		return
+	}
+
+	if err := h.db.Delete(&member).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "removed"})
+}
+
+// Requests lists the pending join requests of a community, oldest first.
+func (h *CommunityHandler) Requests(c *gin.Context) {
+	community, _, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.JoinRequest{}).Where("community_id = ?", community.ID).Count(&total)
+
+	var requests []models.JoinRequest
+	if err := h.db.Preload("User").
+		Where("community_id = ?", community.ID).
+		Order("id").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch join requests"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, gin.H{
+			"user":         utils.UserResponse(request.User),
+			"requested_at": request.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ApproveRequest turns a join request into a membership.
+func (h *CommunityHandler) ApproveRequest(c *gin.Context) {
+	community, _, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := paramID(c, "user_id", "join request not found")
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("community_id = ? AND user_id = ?", community.ID, userID).Delete(&models.JoinRequest{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		member := models.CommunityMember{CommunityID: community.ID, UserID: userID, Role: models.CommunityRoleMember}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "join request not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to approve join request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": models.CommunityRoleMember})
+}
+
+// RejectRequest drops a join request.
+func (h *CommunityHandler) RejectRequest(c *gin.Context) {
+	community, _, ok := h.findManaged(c)
+	if !ok {
+		return
+	}
+
+	userID, ok := paramID(c, "user_id", "join request not found")
+	if !ok {
+		return
+	}
+
+	result := h.db.Where("community_id = ? AND user_id = ?", community.ID, userID).Delete(&models.JoinRequest{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reject join request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "join request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "rejected"})
+}
+
+func (h *CommunityHandler) findCommunity(c *gin.Context) (models.Community, bool) {
+	community, err := service.FindCommunity(h.db, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "community not found"})
+		return community, false
+	}
+	return community, true
+}
+
+// findManaged loads the community in the route for its owner or a
+// moderator, returning the caller's role. Site admins act as owner.
+func (h *CommunityHandler) findManaged(c *gin.Context) (models.Community, string, bool) {
+	community, ok := h.findCommunity(c)
+	if !ok {
+		return community, "", false
+	}
+
+	v := currentViewer(h.db, c)
+	role := service.CommunityRole(h.db, community.ID, v.ID)
+	if v.IsAdmin() {
+		role = models.CommunityRoleOwner
+	}
+	if !service.CanModerateCommunity(role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "community moderator access required"})
+		return community, "", false
+	}
+	return community, role, true
+}
+
+func (h *CommunityHandler) findMember(c *gin.Context, communityID uint) (models.CommunityMember, bool) {
+	var member models.CommunityMember
+	if err := h.db.Preload("User").
+		Where("community_id = ? AND user_id = ?", communityID, c.Param("user_id")).
+		First(&member).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		return member, false
+	}
+	return member, true
+}
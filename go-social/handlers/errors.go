@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/service"
+)
+
+var statusForCode = map[service.Code]int{
+	service.CodeInvalid:            http.StatusBadRequest,
+	service.CodeUnauthenticated:    http.StatusUnauthorized,
+	service.CodeForbidden:          http.StatusForbidden,
+	service.CodeNotFound:           http.StatusNotFound,
+	service.CodeConflict:           http.StatusConflict,
+	service.CodePreconditionFailed: http.StatusPreconditionFailed,
+	service.CodeInternal:           http.StatusInternalServerError,
+}
+
+// respondError writes an error returned by package service with the
+// matching HTTP status.
+func respondError(c *gin.Context, err error) {
+	var e *service.Error
+	if !errors.As(err, &e) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(statusForCode[e.Code], gin.H{"error": e.Message})
+}
+
+// paramID parses a numeric route parameter. Anything that is not a valid ID
+// cannot name an existing record, so it is reported as notFound.
+func paramID(c *gin.Context, name, notFound string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+type ExportHandler struct {
+	db    *gorm.DB
+	audit *audit.Logger
+}
+
+func NewExportHandler(db *gorm.DB, auditLog *audit.Logger) *ExportHandler {
+	return &ExportHandler{db: db, audit: auditLog}
+}
+
+// Request queues a data export for the current user. A request that is
+// already queued or running is returned instead of starting another one.
+func (h *ExportHandler) Request(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var export models.DataExport
+	err := h.db.Where("user_id = ? AND status IN ?", userID, []string{models.ExportPending, models.ExportRunning}).
+		Order("id DESC").First(&export).Error
+	if err == nil {
+		c.JSON(http.StatusAccepted, exportResponse(export))
+		return
+	}
+
+	export = models.DataExport{UserID: userID, Status: models.ExportPending}
+	if err := h.db.Create(&export).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request export"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditExportRequested, Details: fmt.Sprintf("export_id=%d", export.ID)})
+
+	c.Header("Location", fmt.Sprintf("/api/users/me/exports/%d", export.ID))
+	c.JSON(http.StatusAccepted, exportResponse(export))
+}
+
+func (h *ExportHandler) List(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var exports []models.DataExport
+	if err := h.db.Where("user_id = ?", userID).Order("id DESC").Limit(20).Find(&exports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch exports"})
+		return
+	}
+
+	data := make([]gin.H, 0, len(exports))
+	for _, export := range exports {
+		data = append(data, exportResponse(export))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+func (h *ExportHandler) Get(c *gin.Context) {
+	export, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, exportResponse(export))
+}
+
+// Download serves a finished archive to its owner until it expires.
+func (h *ExportHandler) Download(c *gin.Context) {
+	export, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	if export.Status == models.ExportExpired || (export.ExpiresAt != nil && export.ExpiresAt.Before(time.Now())) {
+		c.JSON(http.StatusGone, gin.H{"error": "export has expired"})
+		return
+	}
+	if export.Status != models.ExportReady {
+		c.JSON(http.StatusConflict, gin.H{"error": "export is not ready", "status": export.Status})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &export.UserID, Action: models.AuditExportDownloaded, Details: fmt.Sprintf("export_id=%d", export.ID)})
+
+	c.Header("Cache-Control", "private, no-store")
+	c.FileAttachment(export.FilePath, fmt.Sprintf("go-social-export-%d.zip", export.ID))
+}
+
+// find loads an export owned by the current user. Other users' exports are
+// reported as missing so their IDs are not disclosed.
+func (h *ExportHandler) find(c *gin.Context) (models.DataExport, bool) {
+	userID, _ := middleware.GetUserID(c)
+
+	var export models.DataExport
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&export).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return export, false
+	}
+	return export, true
+}
+
+func exportResponse(export models.DataExport) gin.H {
+	resp := gin.H{
+		"id":           export.ID,
+		"status":       export.Status,
+		"size_bytes":   export.SizeBytes,
+		"created_at":   export.CreatedAt,
+		"started_at":   export.StartedAt,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+	if export.Error != "" {
+		resp["error"] = export.Error
+	}
+	if export.Status == models.ExportReady {
+		resp["download_url"] = fmt.Sprintf("/api/users/me/exports/%d/download", export.ID)
+	}
+	return resp
+}
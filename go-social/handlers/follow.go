@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FollowHandler struct {
+	db *gorm.DB
+}
+
+func NewFollowHandler(db *gorm.DB) *FollowHandler {
+	return &FollowHandler{db: db}
+}
+
+func (h *FollowHandler) Follow(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if target.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot follow yourself"})
+		return
+	}
+
+	if service.IsBlocked(h.db, userID, target.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot interact with this user"})
+		return
+	}
+
+	// Private accounts approve their followers
+	follow := models.Follow{FollowerID: userID, FollowingID: target.ID, Pending: target.Privacy.PrivateAccount}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow user"})
+		return
+	}
+
+	// An existing follow or request is left as it was
+	if err := h.db.Where("follower_id = ? AND following_id = ?", userID, target.ID).First(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"following": !follow.Pending, "requested": follow.Pending})
+}
+
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	target, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := h.db.Where("follower_id = ? AND following_id = ?", userID, target.ID).Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unfollow user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"following": false, "requested": false})
+}
+
+// Followers lists the users following the user named in the route.
+func (h *FollowHandler) Followers(c *gin.Context) {
+	h.listFollows(c, "following_id", "Follower")
+}
+
+// Following lists the users the user named in the route follows.
+func (h *FollowHandler) Following(c *gin.Context) {
+	h.listFollows(c, "follower_id", "Following")
+}
+
+func (h *FollowHandler) listFollows(c *gin.Context, column, preload string) {
+	user, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !currentViewer(h.db, c).CanSeeActivity(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this account is private"})
+		return
+	}
+
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.Follow{}).Where(column+" = ? AND NOT pending", user.ID).Count(&total)
+
+	var follows []models.Follow
+	if err := h.db.Preload(preload).
+		Where(column+" = ? AND NOT pending", user.ID).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch follows"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(follows))
+	for _, follow := range follows {
+		other := follow.Follower
+		if preload == "Following" {
+			other = follow.Following
+		}
+		response = append(response, gin.H{
+			"user":        utils.UserResponse(other),
+			"followed_at": follow.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// FollowRequests lists the caller's pending follow requests, newest first.
+func (h *FollowHandler) FollowRequests(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, pageSize := utils.Paginate(c)
+
+	var total int64
+	h.db.Model(&models.Follow{}).Where("following_id = ? AND pending", userID).Count(&total)
+
+	var follows []models.Follow
+	if err := h.db.Preload("Follower").
+		Where("following_id = ? AND pending", userID).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch follow requests"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(follows))
+	for _, follow := range follows {
+		response = append(response, gin.H{
+			"user":         utils.UserResponse(follow.Follower),
+			"requested_at": follow.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ApproveFollower accepts the follow request from the user named in the
+// route.
+func (h *FollowHandler) ApproveFollower(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	follower, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	result := h.db.Model(&models.Follow{}).
+		Where("follower_id = ? AND following_id = ? AND pending", follower.ID, userID).
+		Update("pending", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to approve follow request"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "follow request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "approved"})
+}
+
+// RemoveFollower declines a pending follow request from the user named in
+// the route, or removes them as a follower.
+func (h *FollowHandler) RemoveFollower(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	follower, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := h.db.Where("follower_id = ? AND following_id = ?", follower.ID, userID).Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove follower"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "removed"})
+}
+
+// Suggestions lists accounts the caller may want to follow, best match
+// first. The ranking is precomputed by jobs.Recommender; follows, blocks
+// and mutes made since the last run are applied here.
+func (h *FollowHandler) Suggestions(c *gin.Context) {
+	v := currentViewer(h.db, c)
+	page, pageSize := utils.Paginate(c)
+
+	suggested := func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN user_suggestions ON user_suggestions.suggested_id = users.id AND user_suggestions.user_id = ?", v.ID).
+			Where("users.id NOT IN (SELECT following_id FROM follows WHERE follower_id = ?)", v.ID).
+			Scopes(service.ExcludeBlocked(v, "users.id"), service.ExcludeMuted(v, "users.id"))
+	}
+
+	var total int64
+	h.db.Model(&models.User{}).Scopes(suggested).Count(&total)
+
+	var users []models.User
+	if err := h.db.Scopes(suggested).
+		Order("user_suggestions.score DESC, users.id").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch suggestions"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(users))
+	for _, user := range users {
+		response = append(response, utils.UserResponse(user))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
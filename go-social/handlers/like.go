@@ -4,53 +4,31 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/krisn2/go-social/middleware"
-	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
 	"gorm.io/gorm"
 )
 
 type LikeHandler struct {
-	db *gorm.DB
+	db  *gorm.DB
+	svc *service.Service
 }
 
 func NewLikeHandler(db *gorm.DB) *LikeHandler {
-	return &LikeHandler{db: db}
+	return &LikeHandler{db: db, svc: service.New(db)}
 }
 
+// Toggle likes the post, or unlikes it if the caller already does.
 func (h *LikeHandler) Toggle(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
-
-	var post models.Post
-	if err := h.db.First(&post, c.Param("id")).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-		return
-	}
-
-	// Check if like exists
-	var like models.Like
-	err := h.db.Where("user_id = ? AND post_id = ?", userID, post.ID).First(&like).Error
-
-	if err == gorm.ErrRecordNotFound {
-		// Create new like
-		newLike := models.Like{UserID: userID, PostID: post.ID}
-		if err := h.db.Create(&newLike).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to like post"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"liked": true})
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
 		return
 	}
 
+	liked, err := h.svc.ToggleLike(currentViewer(h.db, c), postID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "database error"})
-		return
-	}
-
-	// Delete existing like
-	if err := h.db.Delete(&like).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlike post"})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"liked": false})
+	c.JSON(http.StatusOK, gin.H{"liked": liked})
 }
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+type MessageHandler struct {
+	db  *gorm.DB
+	svc *service.Service
+}
+
+func NewMessageHandler(db *gorm.DB) *MessageHandler {
+	return &MessageHandler{db: db, svc: service.New(db)}
+}
+
+type StartConversationRequest struct {
+	// Participants are user IDs or @handles, not including the caller
+	Participants []string `json:"participants" binding:"required,min=1"`
+	Body         string   `json:"body" binding:"max=5000"`
+}
+
+type MessageRequest struct {
+	Body string `json:"body" binding:"required,max=5000"`
+}
+
+type MarkReadRequest struct {
+	MessageID uint `json:"message_id"` // 0 marks everything read
+}
+
+// Start opens a conversation, or returns the existing one when the caller
+// already has a direct conversation with the single participant named.
+func (h *MessageHandler) Start(c *gin.Context) {
+	var req StartConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.svc.StartConversation(currentViewer(h.db, c), req.Participants, req.Body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversationResponse(summary))
+}
+
+// List renders the caller's conversations, most recently active first.
+// Pass the next_cursor of the previous page as ?cursor= to continue.
+func (h *MessageHandler) List(c *gin.Context) {
+	v := currentViewer(h.db, c)
+	_, pageSize := utils.Paginate(c)
+
+	summaries, more, err := h.svc.ConversationPage(v, pageSize, c.Query("cursor"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	unread, err := h.svc.UnreadMessages(v)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(summaries))
+	for _, summary := range summaries {
+		response = append(response, conversationResponse(summary))
+	}
+
+	var nextCursor interface{}
+	if more {
+		nextCursor = service.ConversationCursor(summaries[len(summaries)-1].Conversation)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        response,
+		"page_size":   pageSize,
+		"next_cursor": nextCursor,
+		"unread":      unread,
+	})
+}
+
+func (h *MessageHandler) Get(c *gin.Context) {
+	id, ok := paramID(c, "id", "conversation not found")
+	if !ok {
+		return
+	}
+
+	summary, err := h.svc.GetConversation(currentViewer(h.db, c), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversationResponse(summary))
+}
+
+// Leave removes the caller and the messages they sent from a conversation.
+func (h *MessageHandler) Leave(c *gin.Context) {
+	id, ok := paramID(c, "id", "conversation not found")
+	if !ok {
+		return
+	}
+
+	if err := h.svc.LeaveConversation(currentViewer(h.db, c), id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "left"})
+}
+
+// Messages renders a conversation newest first. Pass the next_cursor of the
+// previous page as ?cursor= for older messages.
+func (h *MessageHandler) Messages(c *gin.Context) {
+	id, ok := paramID(c, "id", "conversation not found")
+	if !ok {
+		return
+	}
+
+	_, pageSize := utils.Paginate(c)
+	messages, more, err := h.svc.MessagePage(currentViewer(h.db, c), id, pageSize, c.Query("cursor"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := make([]gin.H, 0, len(messages))
+	for _, message := range messages {
+		response = append(response, utils.MessageResponse(message))
+	}
+
+	var nextCursor interface{}
+	if more {
+		nextCursor = service.MessageCursor(messages[len(messages)-1])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":        response,
+		"page_size":   pageSize,
+		"next_cursor": nextCursor,
+	})
+}
+
+func (h *MessageHandler) Send(c *gin.Context) {
+	id, ok := paramID(c, "id", "conversation not found")
+	if !ok {
+		return
+	}
+
+	var req MessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.svc.SendMessage(currentViewer(h.db, c), id, req.Body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, utils.MessageResponse(message))
+}
+
+// MarkRead moves the caller's read position forward, which the other
+// participants see as a read receipt.
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	id, ok := paramID(c, "id", "conversation not found")
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req MarkReadRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	participant, err := h.svc.MarkRead(currentViewer(h.db, c), id, req.MessageID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"last_read_message_id": participant.LastReadMessageID,
+		"last_read_at":         participant.LastReadAt,
+	})
+}
+
+func conversationResponse(summary service.ConversationSummary) gin.H {
+	return utils.ConversationResponse(summary.Conversation, summary.Participants, summary.LastMessage, summary.Unread)
+}
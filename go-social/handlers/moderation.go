@@ -0,0 +1,444 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+const defaultSuspendHours = 7 * 24
+
+type ModerationHandler struct {
+	db    *gorm.DB
+	audit *audit.Logger
+}
+
+func NewModerationHandler(db *gorm.DB, auditLog *audit.Logger) *ModerationHandler {
+	return &ModerationHandler{db: db, audit: auditLog}
+}
+
+// RequireModerator stops non-moderators before they reach the moderation
+// routes. It must run after JWTAuth.
+func (h *ModerationHandler) RequireModerator(c *gin.Context) {
+	if !currentViewer(h.db, c).IsModerator() {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "moderator access required"})
+		return
+	}
+	c.Next()
+}
+
+type queueEntry struct {
+	TargetType      string    `json:"target_type"`
+	TargetID        uint      `json:"target_id"`
+	ReportCount     int64     `json:"report_count"`
+	Reasons         string    `json:"-"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+}
+
+// Queue lists reported content grouped by target, most reported first.
+func (h *ModerationHandler) Queue(c *gin.Context) {
+	page, pageSize := utils.Paginate(c)
+	status := c.DefaultQuery("status", models.ReportOpen)
+
+	var total int64
+	h.db.Raw("SELECT COUNT(*) FROM (SELECT 1 FROM reports WHERE status = ? GROUP BY target_type, target_id) AS targets", status).Scan(&total)
+
+	var entries []queueEntry
+	if err := h.db.Model(&models.Report{}).
+		Select(`target_type, target_id, COUNT(*) AS report_count,
+			string_agg(DISTINCT reason, ',') AS reasons,
+			MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at`).
+		Where("status = ?", status).
+		Group("target_type, target_id").
+		Order("report_count DESC, first_reported_at ASC, target_type, target_id").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+
+	// Load the reported posts and comments in two queries
+	var postIDs, commentIDs []uint
+	for _, e := range entries {
+		if e.TargetType == models.TargetPost {
+			postIDs = append(postIDs, e.TargetID)
+		} else {
+			commentIDs = append(commentIDs, e.TargetID)
+		}
+	}
+	targets := h.loadTargets(postIDs, commentIDs)
+
+	response := make([]gin.H, 0, len(entries))
+	for _, e := range entries {
+		response = append(response, gin.H{
+			"target_type":       e.TargetType,
+			"target_id":         e.TargetID,
+			"target":            targets[targetKey(e.TargetType, e.TargetID)],
+			"report_count":      e.ReportCount,
+			"reasons":           strings.Split(e.Reasons, ","),
+			"first_reported_at": e.FirstReportedAt,
+			"last_reported_at":  e.LastReportedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      response,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// TargetReports shows every report and past decision for one target.
+func (h *ModerationHandler) TargetReports(c *gin.Context) {
+	targetType, targetID, ok := parseTarget(c)
+	if !ok {
+		return
+	}
+
+	var reports []models.Report
+	if err := h.db.Preload("Reporter").
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("id DESC").
+		Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+
+	var actions []models.ModerationAction
+	h.db.Where("target_type = ? AND target_id = ?", targetType, targetID).Order("id DESC").Find(&actions)
+
+	reportResponse := make([]gin.H, 0, len(reports))
+	for _, r := range reports {
+		reportResponse = append(reportResponse, gin.H{
+			"id":          r.ID,
+			"reporter":    utils.UserResponse(r.Reporter),
+			"reason":      r.Reason,
+			"details":     r.Details,
+			"status":      r.Status,
+			"created_at":  r.CreatedAt,
+			"resolved_at": r.ResolvedAt,
+		})
+	}
+
+	var postIDs, commentIDs []uint
+	if targetType == models.TargetPost {
+		postIDs = []uint{targetID}
+	} else {
+		commentIDs = []uint{targetID}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"target_type": targetType,
+		"target_id":   targetID,
+		"target":      h.loadTargets(postIDs, commentIDs)[targetKey(targetType, targetID)],
+		"reports":     reportResponse,
+		"actions":     actions,
+	})
+}
+
+type ModerationRequest struct {
+	Action       string `json:"action" binding:"required,oneof=dismiss hide unhide delete warn suspend"`
+	Note         string `json:"note" binding:"max=1000"`
+	SuspendHours int    `json:"suspend_hours" binding:"omitempty,min=1,max=87600"`
+}
+
+// Act applies a moderation decision to a target, closes its open reports
+// and records the decision in the audit trail, all in one transaction. A
+// warning is delivered to the author's warnings.
+func (h *ModerationHandler) Act(c *gin.Context) {
+	moderatorID, _ := middleware.GetUserID(c)
+
+	targetType, targetID, ok := parseTarget(c)
+	if !ok {
+		return
+	}
+
+	var req ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	note := strings.TrimSpace(req.Note)
+	if req.Action == models.ActionWarn && note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a warning needs a note for the author"})
+		return
+	}
+
+	// Find the author; only a dismissal may refer to content that is gone
+	var authorID, commentPostID uint
+	var err error
+	if targetType == models.TargetPost {
+		var post models.Post
+		err = h.db.Select("id, user_id").First(&post, targetID).Error
+		authorID = post.UserID
+	} else {
+		var comment models.Comment
+		err = h.db.Select("id, user_id, post_id").First(&comment, targetID).Error
+		authorID = comment.UserID
+		commentPostID = comment.PostID
+	}
+	if err != nil && req.Action != models.ActionDismiss {
+		c.JSON(http.StatusNotFound, gin.H{"error": targetType + " not found"})
+		return
+	}
+
+	if req.Action == models.ActionSuspend {
+		var author models.User
+		h.db.Select("id, role").First(&author, authorID)
+		if author.Role == models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admins cannot be suspended"})
+			return
+		}
+	}
+
+	action := models.ModerationAction{
+		ModeratorID:   moderatorID,
+		Action:        req.Action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		SubjectUserID: authorID,
+		Note:          note,
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		table := "posts"
+		if targetType == models.TargetComment {
+			table = "comments"
+		}
+
+		reportsClosed := int64(0)
+		switch req.Action {
+		case models.ActionHide, models.ActionUnhide:
+			if err := tx.Table(table).Where("id = ?", targetID).Update("hidden", req.Action == models.ActionHide).Error; err != nil {
+				return err
+			}
+			// Move the post's Last-Modified on so cached copies are refetched
+			postID := targetID
+			if targetType == models.TargetComment {
+				postID = commentPostID
+			}
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).
+				UpdateColumn("activity_at", gorm.Expr("NOW()")).Error; err != nil {
+				return err
+			}
+		case models.ActionDelete:
+			if targetType == models.TargetPost {
+				// Reports on the post's comments go with them
+				result := tx.Model(&models.Report{}).
+					Where("target_type = ? AND status = ? AND target_id IN (SELECT id FROM comments WHERE post_id = ?)",
+						models.TargetComment, models.ReportOpen, targetID).
+					Updates(map[string]interface{}{"status": models.ReportResolved, "resolved_at": time.Now()})
+				if result.Error != nil {
+					return result.Error
+				}
+				reportsClosed = result.RowsAffected
+				if err := service.RemovePost(tx, targetID); err != nil {
+					return err
+				}
+			} else if err := service.RemoveComment(tx, models.Comment{ID: targetID, PostID: commentPostID}); err != nil {
+				return err
+			}
+		case models.ActionWarn:
+			warning := models.Warning{UserID: authorID, TargetType: targetType, TargetID: targetID, Note: note}
+			if err := tx.Create(&warning).Error; err != nil {
+				return err
+			}
+		case models.ActionSuspend:
+			hours := req.SuspendHours
+			if hours == 0 {
+				hours = defaultSuspendHours
+			}
+			until := time.Now().Add(time.Duration(hours) * time.Hour)
+			if err := tx.Model(&models.User{}).Where("id = ?", authorID).Update("suspended_until", until).Error; err != nil {
+				return err
+			}
+		}
+
+		if req.Action != models.ActionUnhide {
+			status := models.ReportResolved
+			if req.Action == models.ActionDismiss {
+				status = models.ReportDismissed
+			}
+			result := tx.Model(&models.Report{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).
+				Updates(map[string]interface{}{"status": status, "resolved_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			reportsClosed += result.RowsAffected
+		}
+
+		action.ReportsClosed = int(reportsClosed)
+		return tx.Create(&action).Error
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to apply moderation action"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{
+		ActorID:    &moderatorID,
+		Action:     models.AuditModerationPrefix + req.Action,
+		TargetType: targetType,
+		TargetID:   &targetID,
+		Details:    fmt.Sprintf("subject_user_id=%d", authorID),
+	})
+
+	c.JSON(http.StatusOK, action)
+}
+
+// Actions lists the moderation audit trail, newest first.
+func (h *ModerationHandler) Actions(c *gin.Context) {
+	page, pageSize := utils.Paginate(c)
+
+	query := h.db.Model(&models.ModerationAction{})
+	for _, filter := range []string{"moderator_id", "subject_user_id", "target_id"} {
+		if v := c.Query(filter); v != "" {
+			if id, err := strconv.ParseUint(v, 10, 32); err == nil {
+				query = query.Where(filter+" = ?", id)
+			}
+		}
+	}
+	for _, filter := range []string{"target_type", "action"} {
+		if v := c.Query(filter); v != "" {
+			query = query.Where(filter+" = ?", v)
+		}
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var actions []models.ModerationAction
+	if err := query.Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&actions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch moderation actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      actions,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// Warnings lists the warnings moderators have sent the caller, newest
+// first.
+func (h *ModerationHandler) Warnings(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	page, pageSize := utils.Paginate(c)
+
+	var total, unread int64
+	h.db.Model(&models.Warning{}).Where("user_id = ?", userID).Count(&total)
+	h.db.Model(&models.Warning{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+	var warnings []models.Warning
+	if err := h.db.Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&warnings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch warnings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":      warnings,
+		"unread":    unread,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// ReadWarning marks one of the caller's warnings as read.
+func (h *ModerationHandler) ReadWarning(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var warning models.Warning
+	if err := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&warning).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "warning not found"})
+		return
+	}
+
+	if warning.ReadAt == nil {
+		now := time.Now()
+		if err := h.db.Model(&warning).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update warning"})
+			return
+		}
+		warning.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, warning)
+}
+
+// loadTargets renders the reported posts and comments keyed by targetKey.
+func (h *ModerationHandler) loadTargets(postIDs, commentIDs []uint) map[string]gin.H {
+	targets := make(map[string]gin.H)
+
+	if len(postIDs) > 0 {
+		var posts []models.Post
+		h.db.Preload("User").Where("id IN ?", postIDs).Find(&posts)
+		for _, p := range posts {
+			targets[targetKey(models.TargetPost, p.ID)] = gin.H{
+				"title":  p.Title,
+				"body":   p.Body,
+				"hidden": p.Hidden,
+				"author": utils.UserResponse(p.User),
+			}
+		}
+	}
+
+	if len(commentIDs) > 0 {
+		var comments []models.Comment
+		h.db.Preload("User").Where("id IN ?", commentIDs).Find(&comments)
+		for _, cm := range comments {
+			targets[targetKey(models.TargetComment, cm.ID)] = gin.H{
+				"post_id": cm.PostID,
+				"body":    cm.Body,
+				"hidden":  cm.Hidden,
+				"author":  utils.UserResponse(cm.User),
+			}
+		}
+	}
+
+	return targets
+}
+
+func targetKey(targetType string, id uint) string {
+	return targetType + ":" + strconv.FormatUint(uint64(id), 10)
+}
+
+func parseTarget(c *gin.Context) (string, uint, bool) {
+	targetType := c.Param("type")
+	if targetType != models.TargetPost && targetType != models.TargetComment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target type must be post or comment"})
+		return "", 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target ID"})
+		return "", 0, false
+	}
+
+	return targetType, uint(id), true
+}
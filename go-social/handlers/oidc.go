@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/oidc"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OIDCHandler signs users in with the OpenID Connect providers in the
+// config and manages the identities linked to their accounts.
+type OIDCHandler struct {
+	db        *gorm.DB
+	cfg       *config.Config
+	audit     *audit.Logger
+	providers *oidc.Registry
+}
+
+func NewOIDCHandler(db *gorm.DB, cfg *config.Config, auditLog *audit.Logger) *OIDCHandler {
+	return &OIDCHandler{db: db, cfg: cfg, audit: auditLog, providers: oidc.NewRegistry(cfg.OIDC)}
+}
+
+// Providers lists the providers users can sign in with.
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.providers.Names()})
+}
+
+// Start begins a sign-in. The client sends the user to the returned
+// authorization_url; the provider redirects back to Callback.
+func (h *OIDCHandler) Start(c *gin.Context) {
+	h.start(c, nil)
+}
+
+// Link begins linking another identity to the current user. It works like
+// Start, and Callback links the identity instead of signing in.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	h.start(c, &userID)
+}
+
+func (h *OIDCHandler) start(c *gin.Context, linkUserID *uint) {
+	provider, ok := h.providers.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+
+	var secrets [4]string
+	for i := range secrets {
+		s, err := oidc.RandomString()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sign-in"})
+			return
+		}
+		secrets[i] = s
+	}
+	state := models.OIDCState{
+		State:        secrets[0],
+		Provider:     provider.Name(),
+		Nonce:        secrets[1],
+		CodeVerifier: secrets[2],
+		BindingHash:  bindingHash(secrets[3]),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(h.cfg.OIDC.StateTTL),
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "provider is unavailable"})
+		return
+	}
+
+	if err := h.db.Create(&state).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sign-in"})
+		return
+	}
+
+	// The callback only completes in the browser that started the flow, so
+	// nobody can hand a victim a sign-in or link URL of their own
+	h.setBindingCookie(c, secrets[3], int(h.cfg.OIDC.StateTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL, "expires_at": state.ExpiresAt})
+}
+
+// Callback is where the provider sends the user back. It redeems the code,
+// verifies the ID token and then signs the user in or links the identity,
+// depending on how the flow was started.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	provider, ok := h.providers.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "provider not found"})
+		return
+	}
+
+	// Each state is deleted on first use, so a replayed callback fails
+	var state models.OIDCState
+	err := h.db.Where("state = ? AND provider = ?", c.Query("state"), provider.Name()).First(&state).Error
+	if err == nil {
+		if result := h.db.Delete(&state); result.Error != nil || result.RowsAffected == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err != nil || state.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sign-in has expired or was already completed; start again"})
+		return
+	}
+
+	binding, _ := c.Cookie(bindingCookie)
+	h.setBindingCookie(c, "", -1)
+	if binding == "" || subtle.ConstantTimeCompare([]byte(bindingHash(binding)), []byte(state.BindingHash)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sign-in was started in another browser; start again"})
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the provider did not complete the sign-in", "provider_error": providerErr})
+		return
+	}
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		h.audit.Record(c, models.AuditEvent{ActorID: state.LinkUserID, Action: models.AuditLoginFailed, Details: "oidc " + provider.Name() + ": " + err.Error()})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "could not verify the sign-in with the provider"})
+		return
+	}
+
+	if state.LinkUserID != nil {
+		h.link(c, *state.LinkUserID, provider.Name(), claims)
+		return
+	}
+	h.signIn(c, provider.Name(), claims)
+}
+
+// signIn finds the account for an identity. An identity seen for the first
+// time joins the account with the same email, or gets a new one. Joining
+// needs the local address to be trusted too: otherwise whoever registered
+// it with a password would share the account, so the owner has to sign in
+// and link the identity instead.
+func (h *OIDCHandler) signIn(c *gin.Context, providerName string, claims oidc.Claims) {
+	var user models.User
+	var identity models.Identity
+	created, linked := false, false
+
+	err := h.db.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&identity).Error
+	if err == nil {
+		err = h.db.First(&user, identity.UserID).Error
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Unverified addresses could claim someone else's account
+		if claims.Email == "" || !claims.EmailVerified {
+			h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, Details: "oidc " + providerName + ": no verified email"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "the provider did not confirm an email address for this account"})
+			return
+		}
+
+		err = h.db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Where("email = ?", claims.Email).First(&user).Error
+			switch {
+			case err == nil:
+				if user.Password != "" && user.EmailVerifiedAt == nil {
+					return errUnverifiedAccount
+				}
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				// Accounts created here have no password until the user
+				// sets one
+				now := time.Now()
+				user = models.User{Name: displayName(claims), Email: claims.Email, EmailVerifiedAt: &now}
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				created = true
+			default:
+				return err
+			}
+			identity = models.Identity{UserID: user.ID, Provider: providerName, Subject: claims.Subject, Email: claims.Email}
+			return tx.Create(&identity).Error
+		})
+		linked = err == nil
+	}
+	if errors.Is(err, errUnverifiedAccount) {
+		h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, TargetType: models.TargetUser, TargetID: &user.ID, Details: "oidc " + providerName + ": email belongs to an unverified account"})
+		c.JSON(http.StatusConflict, gin.H{"error": "an account with this email already exists; sign in and link this provider from your profile"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign in"})
+		return
+	}
+
+	if user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()) {
+		h.audit.Record(c, models.AuditEvent{Action: models.AuditLoginFailed, TargetType: models.TargetUser, TargetID: &user.ID, Details: "account suspended"})
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":           "account suspended",
+			"suspended_until": user.SuspendedUntil,
+		})
+		return
+	}
+
+	now := time.Now()
+	h.db.Model(&identity).UpdateColumn("last_login_at", now)
+
+	token, err := utils.GenerateToken(&user, h.cfg.JWTSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	if created {
+		h.audit.Record(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditRegister, Details: "oidc " + providerName})
+	}
+	if linked {
+		h.audit.Record(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditIdentityLinked, Details: "provider=" + providerName})
+	}
+	h.audit.Record(c, models.AuditEvent{ActorID: &user.ID, Action: models.AuditLogin, Details: "oidc " + providerName})
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"token":   token,
+		"user":    utils.PrivateUserResponse(user),
+		"created": created,
+	})
+}
+
+// link attaches an identity to the user who started the flow. Linking the
+// same identity again is a no-op.
+func (h *OIDCHandler) link(c *gin.Context, userID uint, providerName string, claims oidc.Claims) {
+	var existing models.Identity
+	if err := h.db.Where("provider = ? AND subject = ?", providerName, claims.Subject).First(&existing).Error; err == nil {
+		if existing.UserID != userID {
+			c.JSON(http.StatusConflict, gin.H{"error": "this identity is linked to another account"})
+			return
+		}
+		c.JSON(http.StatusOK, identityResponse(existing))
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.User{}).Where("id = ?", userID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	identity := models.Identity{UserID: userID, Provider: providerName, Subject: claims.Subject, Email: claims.Email}
+	if err := h.db.Create(&identity).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "this identity is linked to another account"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditIdentityLinked, Details: "provider=" + providerName})
+
+	c.JSON(http.StatusCreated, identityResponse(identity))
+}
+
+// ListIdentities returns the identities linked to the current user.
+func (h *OIDCHandler) ListIdentities(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var identities []models.Identity
+	if err := h.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch identities"})
+		return
+	}
+
+	data := make([]gin.H, 0, len(identities))
+	for _, identity := range identities {
+		data = append(data, identityResponse(identity))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Unlink removes an identity from the current user. An account without a
+// password keeps at least one identity, or nobody could sign in to it.
+func (h *OIDCHandler) Unlink(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var identity models.Identity
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so two unlinks cannot both pass the last-identity
+		// check
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, password").First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&identity).Error; err != nil {
+			return errIdentityNotFound
+		}
+
+		if user.Password == "" {
+			var count int64
+			if err := tx.Model(&models.Identity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count <= 1 {
+				return errLastIdentity
+			}
+		}
+		return tx.Delete(&identity).Error
+	})
+	switch {
+	case errors.Is(err, errIdentityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "identity not found"})
+		return
+	case errors.Is(err, errLastIdentity):
+		c.JSON(http.StatusConflict, gin.H{"error": "set a password or link another identity before removing your only way to sign in"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlink identity"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditIdentityUnlinked, Details: fmt.Sprintf("provider=%s identity_id=%d", identity.Provider, identity.ID)})
+
+	c.JSON(http.StatusOK, gin.H{"status": "unlinked"})
+}
+
+// bindingCookie ties an OIDCState to the browser that started the flow.
+const bindingCookie = "oidc_binding"
+
+func bindingHash(binding string) string {
+	sum := sha256.Sum256([]byte(binding))
+	return hex.EncodeToString(sum[:])
+}
+
+// setBindingCookie scopes the cookie to the callbacks under
+// oidc.redirect_url. A negative maxAge deletes it.
+func (h *OIDCHandler) setBindingCookie(c *gin.Context, value string, maxAge int) {
+	path, secure := "/", false
+	if u, err := url.Parse(h.cfg.OIDC.RedirectURL); err == nil {
+		if u.Path != "" {
+			path = u.Path
+		}
+		secure = u.Scheme == "https"
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(bindingCookie, value, maxAge, path, "", secure, true)
+}
+
+var (
+	errIdentityNotFound  = errors.New("identity not found")
+	errLastIdentity      = errors.New("last identity")
+	errUnverifiedAccount = errors.New("unverified account")
+)
+
+// displayName picks a name for an account created at first sign-in,
+// falling back to the email's local part.
+func displayName(claims oidc.Claims) string {
+	name := strings.TrimSpace(claims.Name)
+	if utf8.RuneCountInString(name) < 2 {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if utf8.RuneCountInString(name) < 2 {
+		name = "user"
+	}
+	if runes := []rune(name); len(runes) > 100 {
+		name = string(runes[:100])
+	}
+	return name
+}
+
+func identityResponse(identity models.Identity) gin.H {
+	return gin.H{
+		"id":            identity.ID,
+		"provider":      identity.Provider,
+		"email":         identity.Email,
+		"last_login_at": identity.LastLoginAt,
+		"created_at":    identity.CreatedAt,
+	}
+}
@@ -3,76 +3,276 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/krisn2/go-social/middleware"
 	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
 	"github.com/krisn2/go-social/utils"
 	"gorm.io/gorm"
 )
 
 type PostHandler struct {
-	db *gorm.DB
+	db  *gorm.DB
+	svc *service.Service
 }
 
 func NewPostHandler(db *gorm.DB) *PostHandler {
-	return &PostHandler{db: db}
+	return &PostHandler{db: db, svc: service.New(db)}
 }
 
 type PostRequest struct {
-	Title string `json:"title" binding:"required,min=1,max=200"`
-	Body  string `json:"body" binding:"max=10000"` // Add max length
+	Title       string       `json:"title" binding:"required,min=1,max=200"`
+	Body        string       `json:"body" binding:"max=10000"` // Add max length
+	Status      string       `json:"status" binding:"omitempty,oneof=draft scheduled published"`
+	PublishAt   *time.Time   `json:"publish_at"`
+	Visibility  string       `json:"visibility" binding:"omitempty,oneof=public followers unlisted private"`
+	CommunityID *uint        `json:"community_id"` // only honoured on create
+	Poll        *PollRequest `json:"poll"`         // only honoured on create
+	QuoteOfID   *uint        `json:"quote_of_id"`  // only honoured on create
 }
 
-func (h *PostHandler) Create(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
+type PollRequest struct {
+	Options        []string  `json:"options" binding:"required,min=2,max=6,dive,required,max=100"`
+	ClosesAt       time.Time `json:"closes_at" binding:"required"`
+	MultipleChoice bool      `json:"multiple_choice"`
+}
 
+type VoteRequest struct {
+	OptionIDs []uint `json:"option_ids" binding:"required,min=1,max=6"`
+}
+
+func (h *PostHandler) Create(c *gin.Context) {
 	var req PostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	post := &models.Post{
-		Title:  req.Title,
-		Body:   req.Body,
-		UserID: userID,
+	var poll *service.PollInput
+	if req.Poll != nil {
+		poll = &service.PollInput{
+			Options:        req.Poll.Options,
+			ClosesAt:       req.Poll.ClosesAt,
+			MultipleChoice: req.Poll.MultipleChoice,
+		}
 	}
 
-	if err := h.db.Create(post).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create post"})
+	v := currentViewer(h.db, c)
+	post, err := h.svc.CreatePost(v, service.PostInput{
+		Title:       req.Title,
+		Body:        req.Body,
+		Status:      req.Status,
+		PublishAt:   req.PublishAt,
+		Visibility:  req.Visibility,
+		CommunityID: req.CommunityID,
+		Poll:        poll,
+		QuoteOfID:   req.QuoteOfID,
+	})
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	// Load user for response
-	h.db.Preload("User").First(post, post.ID)
-	h.getPostResponse(c, post)
+	h.getPostResponse(c, v.ID, &post)
 }
 
+// List renders the timeline, ordered by ?sort=new|top|hot|controversial
+// over ?window=day|week|month|all. Besides ?page= it accepts the
+// next_cursor of the previous page as ?cursor=, which stays consistent
+// while the ranking shifts between requests.
 func (h *PostHandler) List(c *gin.Context) {
+	ranking, err := service.ParseRanking(c.Query("sort"), c.Query("window"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	v := currentViewer(h.db, c)
 	page, pageSize := utils.Paginate(c)
+	scopes := append(service.Feed(v), ranking.Scope)
+
+	var total int64
+	h.svc.Posts(v, scopes...).Count(&total)
+
+	query := h.svc.Posts(v, scopes...)
+	cursor := c.Query("cursor")
+	if cursor != "" {
+		if query, err = ranking.After(query, cursor); err != nil {
+			respondError(c, err)
+			return
+		}
+	} else {
+		query = query.Offset((page - 1) * pageSize)
+	}
+
+	var posts []models.Post
+	query.Order(ranking.Order()).Limit(pageSize + 1).Find(&posts)
+
+	var nextCursor interface{}
+	if len(posts) > pageSize {
+		posts = posts[:pageSize]
+		nextCursor = ranking.Cursor(posts[len(posts)-1])
+	}
+
+	h.renderPosts(c, v, posts, gin.H{
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"sort":        ranking.Sort,
+		"window":      ranking.Window,
+		"next_cursor": nextCursor,
+	}, cursor)
+}
+
+// ListByUser lists the posts written by the user named in the route.
+func (h *PostHandler) ListByUser(c *gin.Context) {
+	user, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if !v.CanSeeActivity(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this account is private"})
+		return
+	}
+
+	h.listPosts(c, v, service.ByAuthor(v, user.ID)...)
+}
+
+// ListLikedByUser lists the posts the user named in the route has liked,
+// unless they keep their like history private.
+func (h *PostHandler) ListLikedByUser(c *gin.Context) {
+	user, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if !v.CanSeeActivity(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this account is private"})
+		return
+	}
+	if !v.CanSeeLikes(user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this user's likes are private"})
+		return
+	}
+
+	h.listPosts(c, v, service.LikedBy(v, user.ID)...)
+}
+
+// ListByCommunity lists the posts of the community named by slug in the
+// route. Private communities show them to members only.
+func (h *PostHandler) ListByCommunity(c *gin.Context) {
+	community, err := service.FindCommunity(h.db, c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "community not found"})
+		return
+	}
 
-	// Optimized query with subqueries for counts
-	var posts []struct {
-		models.Post
-		LikesCount    int64 `json:"likes_count"`
-		CommentsCount int64 `json:"comments_count"`
+	v := currentViewer(h.db, c)
+	if !service.CanReadCommunity(h.db, v, community) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this community is private"})
+		return
 	}
 
+	h.listPosts(c, v, append(service.InCommunity(v, community.ID), service.ExcludeMuted(v, "posts.user_id"))...)
+}
+
+// ListRelated lists posts liked by the same people as the post in the
+// route, most similar first, from the results of jobs.Recommender.
+func (h *PostHandler) ListRelated(c *gin.Context) {
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if _, err := h.svc.GetPost(v, postID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	page, pageSize := utils.Paginate(c)
+	scopes := append(service.Feed(v), func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN related_posts ON related_posts.related_id = posts.id AND related_posts.post_id = ?", postID)
+	})
+
 	var total int64
-	h.db.Model(&models.Post{}).Count(&total)
-
-	// Single query with counts
-	h.db.Table("posts").
-		Select(`posts.*, 
-			(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id) as likes_count,
-			(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) as comments_count`).
-		Joins("LEFT JOIN users ON posts.user_id = users.id").
-		Order("posts.id DESC").
+	h.svc.Posts(v, scopes...).Count(&total)
+
+	var posts []models.Post
+	h.svc.Posts(v, scopes...).
+		Order("related_posts.score DESC, posts.id DESC").
 		Limit(pageSize).
 		Offset((page - 1) * pageSize).
 		Find(&posts)
 
+	h.renderPosts(c, v, posts, gin.H{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	}, postID)
+}
+
+// ListBookmarks lists the caller's bookmarked posts, optionally limited to
+// one collection with ?collection_id=.
+func (h *PostHandler) ListBookmarks(c *gin.Context) {
+	v := currentViewer(h.db, c)
+
+	h.listPosts(c, v, service.PublishedPosts, func(db *gorm.DB) *gorm.DB {
+		bookmarks := h.db.Model(&models.Bookmark{}).Select("post_id").Where("user_id = ?", v.ID)
+		if collectionID := c.Query("collection_id"); collectionID != "" {
+			bookmarks = bookmarks.Where("collection_id = ?", collectionID)
+		}
+		return db.Where("posts.id IN (?)", bookmarks)
+	})
+}
+
+// ListDrafts lists the caller's unpublished drafts and scheduled posts.
+func (h *PostHandler) ListDrafts(c *gin.Context) {
+	v := currentViewer(h.db, c)
+
+	h.listPosts(c, v, func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.user_id = ? AND posts.status IN ?", v.ID, []string{models.StatusDraft, models.StatusScheduled})
+	})
+}
+
+// listPosts renders a page of the posts v may see, narrowed by the given
+// scopes. Posts by users on either side of a block are always left out.
+func (h *PostHandler) listPosts(c *gin.Context, v service.Viewer, scopes ...func(*gorm.DB) *gorm.DB) {
+	page, pageSize := utils.Paginate(c)
+
+	var posts []models.Post
+
+	var total int64
+	h.svc.Posts(v, scopes...).Count(&total)
+
+	// Counts come from the denormalized columns, no per-row subqueries
+	h.svc.Posts(v, scopes...).
+		Order("posts.published_at DESC NULLS LAST, posts.id DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&posts)
+
+	h.renderPosts(c, v, posts, gin.H{
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// renderPosts writes a page of posts with their authors, link previews and
+// the caller's bookmark state, merged into meta. meta and etagParts go into
+// the list ETag.
+func (h *PostHandler) renderPosts(c *gin.Context, v service.Viewer, posts []models.Post, meta gin.H, etagParts ...interface{}) {
+	service.AttachLinkPreviews(h.db, posts)
+	service.AttachPolls(h.db, v, posts)
+	h.svc.AttachOriginals(v, posts)
+
 	// Load users separately to avoid N+1
 	var userIDs []uint
 	for _, post := range posts {
@@ -88,18 +288,37 @@ func (h *PostHandler) List(c *gin.Context) {
 		userMap[user.ID] = user
 	}
 
+	var postIDs []uint
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	bookmarked := service.BookmarkedSet(h.db, v.ID, postIDs)
+
+	// The list has no single modification time, since a post dropping out
+	// of the page can make it older, so only the ETag validates it
+	etagParts = append(etagParts, v.ID, meta)
+	for _, post := range posts {
+		etagParts = append(etagParts, postETag(post, v.ID, bookmarked[post.ID], userMap[post.UserID]))
+	}
+	if utils.NotModified(c, utils.ListETag(etagParts...), time.Time{}, v.ID != 0) {
+		return
+	}
+
 	response := make([]gin.H, 0, len(posts))
 	for _, post := range posts {
 		post.User = userMap[post.UserID]
-		response = append(response, utils.PostResponse(post.Post, int(post.LikesCount), int(post.CommentsCount)))
+		item := utils.PostResponse(post)
+		if v.ID != 0 {
+			item["bookmarked"] = bookmarked[post.ID]
+		}
+		response = append(response, item)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":      response,
-		"total":     total,
-		"page":      page,
-		"page_size": pageSize,
-	})
+	body := gin.H{"data": response}
+	for k, val := range meta {
+		body[k] = val
+	}
+	c.JSON(http.StatusOK, body)
 }
 
 func (h *PostHandler) Get(c *gin.Context) {
@@ -109,97 +328,157 @@ func (h *PostHandler) Get(c *gin.Context) {
 		return
 	}
 
-	var post models.Post
-	if err := h.db.Preload("User").First(&post, uint(postID)).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	v := currentViewer(h.db, c)
+	post, err := h.svc.GetPost(v, uint(postID))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	h.getPostResponse(c, &post)
+	h.getPostResponse(c, v.ID, &post)
 }
 
 func (h *PostHandler) Update(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
 	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
 		return
 	}
 
-	var post models.Post
-	if err := h.db.First(&post, uint(postID)).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	var req PostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not the post owner"})
+	// Optimistic concurrency: If-Match must name the version being edited
+	v := currentViewer(h.db, c)
+	post, err := h.svc.UpdatePost(v, uint(postID), service.PostUpdate{
+		Title:      &req.Title,
+		Body:       &req.Body,
+		Status:     req.Status,
+		PublishAt:  req.PublishAt,
+		Visibility: req.Visibility,
+	}, c.GetHeader("If-Match"))
+	if err != nil {
+		if err == service.ErrPostChanged {
+			// Hand back the ETag a GET would return, so the client can
+			// retry against the current version
+			if current, err := h.svc.GetPost(v, uint(postID)); err == nil {
+				bookmarked := v.ID != 0 && service.BookmarkedSet(h.db, v.ID, []uint{current.ID})[current.ID]
+				c.Header("ETag", postETag(current, v.ID, bookmarked, current.User))
+			}
+		}
+		respondError(c, err)
 		return
 	}
 
-	var req PostRequest
+	h.getPostResponse(c, v.ID, &post)
+}
+
+// Vote casts the caller's ballot in the post's poll and renders the post
+// with the results.
+func (h *PostHandler) Vote(c *gin.Context) {
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.db.Model(&post).Updates(models.Post{Title: req.Title, Body: req.Body}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update post"})
+	v := currentViewer(h.db, c)
+	post, err := h.svc.Vote(v, postID, req.OptionIDs)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	h.db.Preload("User").First(&post, post.ID)
-	h.getPostResponse(c, &post)
+	h.getPostResponse(c, v.ID, &post)
 }
 
-func (h *PostHandler) Delete(c *gin.Context) {
-	userID, _ := middleware.GetUserID(c)
-	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+// Repost shares a public post on the caller's timeline.
+func (h *PostHandler) Repost(c *gin.Context) {
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	post, err := h.svc.Repost(v, postID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		respondError(c, err)
 		return
 	}
 
-	var post models.Post
-	if err := h.db.First(&post, uint(postID)).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+	h.getPostResponse(c, v.ID, &post)
+}
+
+// Unrepost takes back the caller's repost of the post in the route.
+func (h *PostHandler) Unrepost(c *gin.Context) {
+	postID, ok := paramID(c, "id", "post not found")
+	if !ok {
 		return
 	}
 
-	if post.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "not the post owner"})
+	if err := h.svc.Unrepost(currentViewer(h.db, c), postID); err != nil {
+		respondError(c, err)
 		return
 	}
 
-	err = h.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("post_id = ?", post.ID).Delete(&models.Comment{}).Error; err != nil {
-			return err
-		}
-		if err := tx.Where("post_id = ?", post.ID).Delete(&models.Like{}).Error; err != nil {
-			return err
-		}
-		return tx.Delete(&models.Post{}, post.ID).Error
-	})
+	c.JSON(http.StatusOK, gin.H{"reposted": false})
+}
 
+func (h *PostHandler) Delete(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete post"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+
+	if err := h.svc.DeletePost(currentViewer(h.db, c), uint(postID)); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
 
-func (h *PostHandler) getPostResponse(c *gin.Context, post *models.Post) {
-	likesCount, commentsCount := h.getCounts(post.ID)
-	c.JSON(http.StatusOK, utils.PostResponse(*post, likesCount, commentsCount))
-}
+// getPostResponse renders a single post for userID, who is 0 when the caller
+// is anonymous. GET requests honour If-None-Match, and If-Modified-Since
+// when the caller is anonymous.
+func (h *PostHandler) getPostResponse(c *gin.Context, userID uint, post *models.Post) {
+	response := utils.PostResponse(*post)
+	bookmarked := false
+	if userID != 0 {
+		bookmarked = service.BookmarkedSet(h.db, userID, []uint{post.ID})[post.ID]
+		response["bookmarked"] = bookmarked
+	}
 
-func (h *PostHandler) getCounts(postID uint) (int, int) {
-	var likesCount int64
-	var commentsCount int64
+	etag := postETag(*post, userID, bookmarked, post.User)
+	if c.Request.Method == http.MethodGet {
+		// The bookmark and like flags have no modification time, so
+		// responses for a signed-in caller are validated by the ETag alone
+		var lastModified time.Time
+		if userID == 0 {
+			lastModified = utils.PostLastModified(*post)
+		}
+		if utils.NotModified(c, etag, lastModified, userID != 0) {
+			return
+		}
+	} else {
+		c.Header("ETag", etag)
+	}
 
-	h.db.Model(&models.Like{}).Where("post_id = ?", postID).Count(&likesCount)
-	h.db.Model(&models.Comment{}).Where("post_id = ?", postID).Count(&commentsCount)
+	c.JSON(http.StatusOK, response)
+}
 
-	return int(likesCount), int(commentsCount)
+// postETag is the ETag of a post as rendered for viewerID, covering the
+// bookmark flag and the embedded author. Single posts, lists and 412
+// responses must all use it so their validators agree.
+func postETag(post models.Post, viewerID uint, bookmarked bool, author models.User) string {
+	return utils.PostETag(post, viewerID, bookmarked, author.UpdatedAt.UnixMicro())
 }
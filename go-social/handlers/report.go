@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportHandler struct {
+	db *gorm.DB
+}
+
+func NewReportHandler(db *gorm.DB) *ReportHandler {
+	return &ReportHandler{db: db}
+}
+
+type ReportRequest struct {
+	Reason  string `json:"reason" binding:"required"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+func (h *ReportHandler) ReportPost(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	post, err := service.FindVisiblePost(h.db, currentViewer(h.db, c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		return
+	}
+
+	if post.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot report your own post"})
+		return
+	}
+
+	h.createReport(c, userID, models.TargetPost, post.ID)
+}
+
+func (h *ReportHandler) ReportComment(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	v := currentViewer(h.db, c)
+
+	var comment models.Comment
+	if err := h.db.Scopes(service.VisibleComments(v)).First(&comment, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return
+	}
+
+	if _, err := service.FindVisiblePost(h.db, v, comment.PostID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return
+	}
+
+	if comment.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot report your own comment"})
+		return
+	}
+
+	h.createReport(c, userID, models.TargetComment, comment.ID)
+}
+
+func (h *ReportHandler) createReport(c *gin.Context, userID uint, targetType string, targetID uint) {
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	if !validReason(reason) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid reason",
+			"reasons": models.ReportReasons,
+		})
+		return
+	}
+
+	report := models.Report{
+		ReporterID: userID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    strings.TrimSpace(req.Details),
+	}
+
+	// A repeat report while the first is still open is a no-op
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create report"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"status": "reported"})
+}
+
+func validReason(reason string) bool {
+	for _, r := range models.ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
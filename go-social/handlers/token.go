@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+type TokenHandler struct {
+	db    *gorm.DB
+	audit *audit.Logger
+}
+
+func NewTokenHandler(db *gorm.DB, auditLog *audit.Logger) *TokenHandler {
+	return &TokenHandler{db: db, audit: auditLog}
+}
+
+type TokenRequest struct {
+	Name      string     `json:"name" binding:"required,min=1,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil for a token that never expires
+}
+
+// List returns the current user's personal access tokens, without the
+// tokens themselves.
+func (h *TokenHandler) List(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var tokens []models.AccessToken
+	if err := h.db.Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tokens"})
+		return
+	}
+
+	data := make([]gin.H, 0, len(tokens))
+	for _, token := range tokens {
+		data = append(data, tokenResponse(token))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Create issues a personal access token. The response is the only time the
+// token is shown.
+func (h *TokenHandler) Create(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := make(map[string]bool, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !models.ValidScope(scope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope " + scope, "scopes": models.TokenScopes})
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.AccessToken{}).Where("user_id = ?", userID).Count(&count)
+	if count >= models.MaxAccessTokens {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("you can have at most %d tokens", models.MaxAccessTokens)})
+		return
+	}
+
+	raw, hash, err := utils.GenerateAccessToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	token := models.AccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hash,
+		Hint:      raw[:len(utils.AccessTokenPrefix)+4],
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := h.db.Create(&token).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditTokenCreated, Details: fmt.Sprintf("token_id=%d scopes=%s", token.ID, token.Scopes)})
+
+	resp := tokenResponse(token)
+	resp["token"] = raw
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Revoke deletes one of the current user's tokens; it stops working
+// immediately.
+func (h *TokenHandler) Revoke(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	result := h.db.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.AccessToken{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditTokenRevoked, Details: "token_id=" + c.Param("id")})
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+func tokenResponse(token models.AccessToken) gin.H {
+	return gin.H{
+		"id":           token.ID,
+		"name":         token.Name,
+		"hint":         token.Hint,
+		"scopes":       token.ScopeList(),
+		"expires_at":   token.ExpiresAt,
+		"expired":      token.Expired(time.Now()),
+		"last_used_at": token.LastUsedAt,
+		"created_at":   token.CreatedAt,
+	}
+}
@@ -1,23 +1,33 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
 	"github.com/krisn2/go-social/config"
 	"github.com/krisn2/go-social/middleware"
 	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/service"
 	"github.com/krisn2/go-social/utils"
 	"gorm.io/gorm"
 )
 
 type UserHandler struct {
-	db  *gorm.DB
-	cfg *config.Config
+	db    *gorm.DB
+	cfg   *config.Config
+	audit *audit.Logger
 }
 
-func NewUserHandler(db *gorm.DB, cfg *config.Config) *UserHandler {
-	return &UserHandler{db: db, cfg: cfg}
+func NewUserHandler(db *gorm.DB, cfg *config.Config, auditLog *audit.Logger) *UserHandler {
+	return &UserHandler{db: db, cfg: cfg, audit: auditLog}
 }
 
 func (h *UserHandler) GetMe(c *gin.Context) {
@@ -29,15 +39,72 @@ func (h *UserHandler) GetMe(c *gin.Context) {
 		return
 	}
 
-	response := utils.UserResponse(user)
+	response := h.profileResponse(currentViewer(h.db, c), user)
+	response["email"] = user.Email
+	response["role"] = user.Role
+	response["privacy"] = user.Privacy
 	response["created_at"] = user.CreatedAt
 	response["updated_at"] = user.UpdatedAt
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GetProfile returns the public profile for a numeric ID or an @handle.
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, err := service.FindUser(h.db, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	v := currentViewer(h.db, c)
+	if !v.CanSeeActivity(user) {
+		response := utils.UserResponse(user)
+		response["private"] = true
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	response := h.profileResponse(v, user)
+	if v.CanSeePrivate(user) {
+		response["email"] = user.Email
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// profileResponse counts only the posts, likes and comments v could browse,
+// so engagement on drafts and restricted posts stays private.
+func (h *UserHandler) profileResponse(v service.Viewer, user models.User) gin.H {
+	var postsCount, likesCount, commentsCount int64
+
+	posts := func() *gorm.DB {
+		return h.db.Model(&models.Post{}).Scopes(service.VisiblePosts(v)).Scopes(service.ByAuthor(v, user.ID)...)
+	}
+	posts().Count(&postsCount)
+	posts().Select("COALESCE(SUM(posts.likes_count), 0)").Scan(&likesCount)
+	h.db.Model(&models.Comment{}).
+		Scopes(service.VisibleComments(v)).
+		Where("comments.user_id = ? AND comments.post_id IN (?)", user.ID,
+			h.db.Model(&models.Post{}).Select("posts.id").Scopes(service.VisiblePosts(v), service.PublishedPosts)).
+		Count(&commentsCount)
+
+	var followersCount, followingCount int64
+	h.db.Model(&models.Follow{}).Where("following_id = ? AND NOT pending", user.ID).Count(&followersCount)
+	h.db.Model(&models.Follow{}).Where("follower_id = ? AND NOT pending", user.ID).Count(&followingCount)
+
+	response := utils.ProfileResponse(user, int(postsCount), int(likesCount), int(commentsCount))
+	response["followers"] = followersCount
+	response["following"] = followingCount
+	return response
+}
+
+// Pointer fields distinguish "not sent" from "cleared"
 type UpdateUserRequest struct {
-	Name string `json:"name" binding:"omitempty,min=2,max=100"`
+	Name      *string `json:"name" binding:"omitempty,min=2,max=100"`
+	Handle    *string `json:"handle" binding:"omitempty,max=31"`
+	Bio       *string `json:"bio" binding:"omitempty,max=500"`
+	AvatarURL *string `json:"avatar_url" binding:"omitempty,max=500"`
 }
 
 func (h *UserHandler) UpdateMe(c *gin.Context) {
@@ -49,18 +116,245 @@ func (h *UserHandler) UpdateMe(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Update("name", req.Name).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		// The binding counts surrounding spaces, so check again once trimmed
+		name := strings.TrimSpace(*req.Name)
+		if utf8.RuneCountInString(name) < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be at least 2 characters"})
+			return
+		}
+		updates["name"] = name
+	}
+	if req.Bio != nil {
+		updates["bio"] = strings.TrimSpace(*req.Bio)
+	}
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if avatarURL != "" {
+			u, err := url.Parse(avatarURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "avatar_url must be an http or https URL"})
+				return
+			}
+		}
+		updates["avatar_url"] = avatarURL
+	}
+	if req.Handle != nil {
+		if *req.Handle == "" {
+			updates["handle"] = nil
+		} else {
+			handle, err := utils.NormalizeHandle(*req.Handle)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			var taken int64
+			h.db.Model(&models.User{}).Where("handle = ? AND id <> ?", handle, userID).Count(&taken)
+			if taken > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "handle already taken"})
+				return
+			}
+			updates["handle"] = handle
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+				c.JSON(http.StatusConflict, gin.H{"error": "handle already taken"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+			return
+		}
+	}
+
+	h.GetMe(c)
+}
+
+type UpdateCredentialsRequest struct {
+	CurrentPassword string  `json:"current_password"` // not needed by accounts without a password
+	Email           *string `json:"email" binding:"omitempty,email"`
+	NewPassword     *string `json:"new_password" binding:"omitempty,min=6,max=72"`
+}
+
+// UpdateCredentials changes the email address and/or password. Both require
+// the current password.
+func (h *UserHandler) UpdateCredentials(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req UpdateCredentialsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Email == nil && req.NewPassword == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email or new_password is required"})
+		return
+	}
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
 
+	// Accounts created through an OpenID Connect provider have no password
+	// until they set one here
+	if user.Password != "" {
+		if err := utils.CheckPassword(user.Password, req.CurrentPassword); err != nil {
+			h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditReauthFailed})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+			return
+		}
+	}
+
+	updates := map[string]interface{}{}
+	var events []models.AuditEvent
+	if req.Email != nil {
+		email := strings.ToLower(*req.Email)
+		if email != user.Email {
+			updates["email"] = email
+			updates["email_verified_at"] = nil
+			events = append(events, models.AuditEvent{ActorID: &userID, Action: models.AuditEmailChanged, Details: user.Email + " -> " + email})
+		}
+	}
+	if req.NewPassword != nil {
+		hashedPassword, err := utils.HashPassword(*req.NewPassword)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			return
+		}
+		updates["password"] = hashedPassword
+		events = append(events, models.AuditEvent{ActorID: &userID, Action: models.AuditPasswordChanged})
+	}
+
+	if len(updates) > 0 {
+		if err := h.db.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+				c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update credentials"})
+			return
+		}
+	}
+
+	for _, event := range events {
+		h.audit.Record(c, event)
+	}
+
 	h.GetMe(c)
 }
 
+func (h *UserHandler) GetPrivacy(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var user models.User
+	if err := h.db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, user.Privacy)
+}
+
+type UpdatePrivacyRequest struct {
+	PrivateAccount *bool   `json:"private_account"`
+	HideLikes      *bool   `json:"hide_likes"`
+	WhoCanComment  *string `json:"who_can_comment" binding:"omitempty,oneof=everyone followers nobody"`
+}
+
+func (h *UserHandler) UpdatePrivacy(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req UpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.PrivateAccount != nil {
+		updates["privacy_private_account"] = *req.PrivateAccount
+	}
+	if req.HideLikes != nil {
+		updates["privacy_hide_likes"] = *req.HideLikes
+	}
+	if req.WhoCanComment != nil {
+		updates["privacy_who_can_comment"] = *req.WhoCanComment
+	}
+
+	if len(updates) > 0 {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+				return err
+			}
+			// A public account has nothing to approve
+			if req.PrivateAccount != nil && !*req.PrivateAccount {
+				return tx.Model(&models.Follow{}).Where("following_id = ? AND pending", userID).Update("pending", false).Error
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update privacy settings"})
+			return
+		}
+	}
+
+	h.GetPrivacy(c)
+}
+
 func (h *UserHandler) DeleteMe(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
 
+	var exportFiles []string
 	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Drop export records; the archives are removed once this commits
+		if err := tx.Model(&models.DataExport{}).Where("user_id = ? AND file_path <> ''", userID).
+			Pluck("file_path", &exportFiles).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.DataExport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.AccessToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Identity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("link_user_id = ?", userID).Delete(&models.OIDCState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Warning{}).Error; err != nil {
+			return err
+		}
+
+		// Close open reports against the user's posts and comments and the
+		// comments on their posts, which all go below
+		if err := tx.Model(&models.Report{}).
+			Where("status = ?", models.ReportOpen).
+			Where(`(target_type = ? AND target_id IN (SELECT id FROM posts WHERE user_id = ?)) OR
+				(target_type = ? AND target_id IN (SELECT id FROM comments WHERE user_id = ? OR post_id IN (SELECT id FROM posts WHERE user_id = ?)))`,
+				models.TargetPost, userID, models.TargetComment, userID, userID).
+			Updates(map[string]interface{}{"status": models.ReportResolved, "resolved_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		// Take the user's comments and likes off other posts' counters
+		if err := tx.Exec(`UPDATE posts SET comments_count = posts.comments_count - c.n, activity_at = NOW()
+			FROM (SELECT post_id, COUNT(*) AS n FROM comments WHERE user_id = ? GROUP BY post_id) AS c
+			WHERE posts.id = c.post_id`, userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE posts SET likes_count = likes_count - 1, activity_at = NOW()
+			WHERE id IN (SELECT post_id FROM likes WHERE user_id = ?)`, userID).Error; err != nil {
+			return err
+		}
+
 		// Delete user's comments and likes
 		if err := tx.Where("user_id = ?", userID).Delete(&models.Comment{}).Error; err != nil {
 			return err
@@ -69,6 +363,60 @@ func (h *UserHandler) DeleteMe(c *gin.Context) {
 			return err
 		}
 
+		// Withdraw the user's poll votes
+		if err := service.RemoveBallots(tx, userID); err != nil {
+			return err
+		}
+
+		// Delete blocks and mutes in either direction
+		if err := tx.Where("blocker_id = ? OR blocked_id = ?", userID, userID).Delete(&models.Block{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("muter_id = ? OR muted_id = ?", userID, userID).Delete(&models.Mute{}).Error; err != nil {
+			return err
+		}
+
+		// Delete the user's bookmarks and collections
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Bookmark{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.BookmarkCollection{}).Error; err != nil {
+			return err
+		}
+
+		// Delete follows in either direction
+		if err := tx.Where("follower_id = ? OR following_id = ?", userID, userID).Delete(&models.Follow{}).Error; err != nil {
+			return err
+		}
+
+		// Delete follow suggestions for and of the user
+		if err := tx.Where("user_id = ? OR suggested_id = ?", userID, userID).Delete(&models.UserSuggestion{}).Error; err != nil {
+			return err
+		}
+
+		// Leave every conversation, taking the user's messages with them
+		var conversationIDs []uint
+		if err := tx.Model(&models.Participant{}).Where("user_id = ?", userID).
+			Pluck("conversation_id", &conversationIDs).Error; err != nil {
+			return err
+		}
+		if err := service.RemoveParticipant(tx, userID, conversationIDs); err != nil {
+			return err
+		}
+
+		// Delete reports the user filed
+		if err := tx.Where("reporter_id = ?", userID).Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+
+		// Take the user's reposts and quotes off the originals' counters
+		if err := tx.Exec(`UPDATE posts SET repost_count = posts.repost_count - r.n, activity_at = NOW()
+			FROM (SELECT COALESCE(repost_of_id, quote_of_id) AS post_id, COUNT(*) AS n FROM posts
+				WHERE user_id = ? AND COALESCE(repost_of_id, quote_of_id) IS NOT NULL GROUP BY 1) AS r
+			WHERE posts.id = r.post_id`, userID).Error; err != nil {
+			return err
+		}
+
 		// Get user's posts
 		var posts []models.Post
 		if err := tx.Where("user_id = ?", userID).Find(&posts).Error; err != nil {
@@ -82,17 +430,47 @@ func (h *UserHandler) DeleteMe(c *gin.Context) {
 				postIDs = append(postIDs, post.ID)
 			}
 
+			// Other users' plain reposts go too; their quotes become stubs
+			var reposts []uint
+			if err := tx.Model(&models.Post{}).Where("repost_of_id IN ? AND user_id <> ?", postIDs, userID).
+				Pluck("id", &reposts).Error; err != nil {
+				return err
+			}
+			for _, id := range reposts {
+				if err := service.RemovePost(tx, id); err != nil {
+					return err
+				}
+			}
+
 			if err := tx.Where("post_id IN ?", postIDs).Delete(&models.Comment{}).Error; err != nil {
 				return err
 			}
 			if err := tx.Where("post_id IN ?", postIDs).Delete(&models.Like{}).Error; err != nil {
 				return err
 			}
+			if err := tx.Where("post_id IN ?", postIDs).Delete(&models.Bookmark{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("post_id IN ?", postIDs).Delete(&models.LinkPreview{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("post_id IN ? OR related_id IN ?", postIDs, postIDs).Delete(&models.RelatedPost{}).Error; err != nil {
+				return err
+			}
+			if err := service.RemovePolls(tx, postIDs); err != nil {
+				return err
+			}
 			if err := tx.Where("id IN ?", postIDs).Delete(&models.Post{}).Error; err != nil {
 				return err
 			}
 		}
 
+		// Leave communities, once the user's own posts no longer count
+		// towards keeping an ownerless community
+		if err := service.LeaveCommunities(tx, userID); err != nil {
+			return err
+		}
+
 		// Delete user
 		return tx.Delete(&models.User{}, userID).Error
 	})
@@ -102,17 +480,30 @@ func (h *UserHandler) DeleteMe(c *gin.Context) {
 		return
 	}
 
+	for _, path := range exportFiles {
+		os.Remove(path)
+	}
+
+	h.audit.Record(c, models.AuditEvent{ActorID: &userID, Action: models.AuditAccountDeleted})
+
 	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
 }
 
 func (h *UserHandler) ListUsers(c *gin.Context) {
+	var actorID *uint
+	if userID, err := middleware.GetUserID(c); err == nil {
+		actorID = &userID
+	}
+
 	// Demo admin check - replace with proper RBAC in production
 	if c.Query("admin_secret") != h.cfg.AdminListSecret {
+		h.audit.Record(c, models.AuditEvent{ActorID: actorID, Action: models.AuditListUsersDenied})
 		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
 
 	page, pageSize := utils.Paginate(c)
+	h.audit.Record(c, models.AuditEvent{ActorID: actorID, Action: models.AuditListUsers, Details: fmt.Sprintf("page=%d page_size=%d", page, pageSize)})
 
 	var users []models.User
 	var total int64
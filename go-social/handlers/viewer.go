@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/middleware"
+	"github.com/krisn2/go-social/service"
+	"gorm.io/gorm"
+)
+
+// currentViewer identifies the caller for the read rules in package
+// service. Anonymous callers get the zero Viewer.
+func currentViewer(db *gorm.DB, c *gin.Context) service.Viewer {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return service.Viewer{}
+	}
+	return service.LoadViewer(db, userID)
+}
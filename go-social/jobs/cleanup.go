@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// Janitor deletes rows that are only kept for a limited time.
+type Janitor struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+func NewJanitor(db *gorm.DB, interval time.Duration) *Janitor {
+	return &Janitor{db: db, interval: interval}
+}
+
+// Run purges expired rows every interval until ctx is cancelled.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		result := j.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{})
+		if result.Error != nil {
+			log.Printf("janitor: failed to purge idempotency keys: %v", result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("janitor: purged %d expired idempotency keys", result.RowsAffected)
+		}
+
+		result = j.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{})
+		if result.Error != nil {
+			log.Printf("janitor: failed to purge sign-in states: %v", result.Error)
+		} else if result.RowsAffected > 0 {
+			log.Printf("janitor: purged %d abandoned sign-in states", result.RowsAffected)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
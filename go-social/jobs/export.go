@@ -0,0 +1,315 @@
+package jobs
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// exportFormatVersion is bumped whenever the archive layout changes.
+const exportFormatVersion = 1
+
+// staleExportAfter is how long a running export may go without finishing
+// before it is assumed lost to a crash and retried.
+const staleExportAfter = 30 * time.Minute
+
+// Exporter builds personal data export archives in the background.
+type Exporter struct {
+	db       *gorm.DB
+	dir      string
+	ttl      time.Duration
+	interval time.Duration
+}
+
+func NewExporter(db *gorm.DB, dir string, ttl, interval time.Duration) *Exporter {
+	return &Exporter{db: db, dir: dir, ttl: ttl, interval: interval}
+}
+
+// Run processes pending exports and expires old archives every interval
+// until ctx is cancelled.
+func (e *Exporter) Run(ctx context.Context) {
+	if err := os.MkdirAll(e.dir, 0o700); err != nil {
+		log.Printf("exporter: cannot create %s: %v", e.dir, err)
+		return
+	}
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			export, err := e.claim(ctx)
+			if err != nil {
+				log.Printf("exporter: failed to claim export: %v", err)
+				break
+			}
+			if export == nil {
+				break
+			}
+			e.process(ctx, export)
+		}
+
+		if err := e.expire(ctx); err != nil {
+			log.Printf("exporter: failed to expire exports: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the oldest pending (or abandoned) export as running and
+// returns it. SKIP LOCKED keeps concurrent workers off the same row.
+func (e *Exporter) claim(ctx context.Context) (*models.DataExport, error) {
+	var exports []models.DataExport
+	err := e.db.WithContext(ctx).Raw(`
+		UPDATE data_exports SET status = ?, started_at = NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = ? OR (status = ? AND started_at < ?)
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		models.ExportRunning, models.ExportPending, models.ExportRunning, time.Now().Add(-staleExportAfter),
+	).Scan(&exports).Error
+	if err != nil || len(exports) == 0 {
+		return nil, err
+	}
+	return &exports[0], nil
+}
+
+func (e *Exporter) process(ctx context.Context, export *models.DataExport) {
+	path, size, err := e.build(ctx, export)
+	now := time.Now()
+
+	updates := map[string]interface{}{"completed_at": now}
+	if err != nil {
+		log.Printf("exporter: export %d failed: %v", export.ID, err)
+		updates["status"] = models.ExportFailed
+		updates["error"] = "failed to build the archive"
+	} else {
+		updates["status"] = models.ExportReady
+		updates["file_path"] = path
+		updates["size_bytes"] = size
+		updates["expires_at"] = now.Add(e.ttl)
+	}
+
+	result := e.db.Model(export).Updates(updates)
+	if result.Error != nil {
+		log.Printf("exporter: failed to record export %d: %v", export.ID, result.Error)
+		return
+	}
+
+	// The account was deleted while the archive was built; nothing would
+	// ever expire the file
+	if result.RowsAffected == 0 && path != "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("exporter: failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+// expire deletes archives whose download window has passed.
+func (e *Exporter) expire(ctx context.Context) error {
+	var expired []models.DataExport
+	if err := e.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.ExportReady, time.Now()).
+		Find(&expired).Error; err != nil {
+		return err
+	}
+
+	for _, export := range expired {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("exporter: failed to remove %s: %v", export.FilePath, err)
+			continue
+		}
+		e.db.Model(&export).Updates(map[string]interface{}{"status": models.ExportExpired, "file_path": ""})
+	}
+	return nil
+}
+
+type manifestFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Bytes   int    `json:"bytes"`
+	SHA256  string `json:"sha256"`
+}
+
+type manifest struct {
+	FormatVersion int            `json:"format_version"`
+	ExportID      uint           `json:"export_id"`
+	UserID        uint           `json:"user_id"`
+	GeneratedAt   time.Time      `json:"generated_at"`
+	Files         []manifestFile `json:"files"`
+	// Media lists uploaded files. go-social stores no uploads itself (an
+	// avatar is only a URL, kept in profile.json), so it is always empty.
+	Media []manifestFile `json:"media"`
+}
+
+type exportPost struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Status      string     `json:"status"`
+	Visibility  string     `json:"visibility"`
+	PublishAt   *time.Time `json:"publish_at"`
+	PublishedAt *time.Time `json:"published_at"`
+	Likes       int        `json:"likes" gorm:"column:likes_count"`
+	Comments    int        `json:"comments" gorm:"column:comments_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type exportComment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type exportLike struct {
+	PostID    uint      `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type exportBookmark struct {
+	PostID       uint      `json:"post_id"`
+	CollectionID *uint     `json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type exportBookmarks struct {
+	Collections []models.BookmarkCollection `json:"collections"`
+	Bookmarks   []exportBookmark            `json:"bookmarks"`
+}
+
+// build writes the archive for export to a temporary file and renames it
+// into place once complete.
+func (e *Exporter) build(ctx context.Context, export *models.DataExport) (string, int64, error) {
+	db := e.db.WithContext(ctx)
+
+	var user models.User
+	if err := db.First(&user, export.UserID).Error; err != nil {
+		return "", 0, err
+	}
+
+	var posts []exportPost
+	if err := db.Model(&models.Post{}).Where("user_id = ?", user.ID).Order("id").Scan(&posts).Error; err != nil {
+		return "", 0, err
+	}
+	var comments []exportComment
+	if err := db.Model(&models.Comment{}).Where("user_id = ?", user.ID).Order("id").Scan(&comments).Error; err != nil {
+		return "", 0, err
+	}
+	var likes []exportLike
+	if err := db.Model(&models.Like{}).Where("user_id = ?", user.ID).Order("id").Scan(&likes).Error; err != nil {
+		return "", 0, err
+	}
+	var bookmarks []exportBookmark
+	if err := db.Model(&models.Bookmark{}).Where("user_id = ?", user.ID).Order("id").Scan(&bookmarks).Error; err != nil {
+		return "", 0, err
+	}
+	var collections []models.BookmarkCollection
+	if err := db.Where("user_id = ?", user.ID).Order("id").Find(&collections).Error; err != nil {
+		return "", 0, err
+	}
+
+	tmp, err := os.CreateTemp(e.dir, "export-*.zip.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	m := manifest{
+		FormatVersion: exportFormatVersion,
+		ExportID:      export.ID,
+		UserID:        user.ID,
+		GeneratedAt:   time.Now().UTC(),
+		Media:         []manifestFile{},
+	}
+
+	files := []struct {
+		name    string
+		records int
+		data    interface{}
+	}{
+		{"profile.json", 1, user},
+		{"posts.json", len(posts), nonNil(posts)},
+		{"comments.json", len(comments), nonNil(comments)},
+		{"likes.json", len(likes), nonNil(likes)},
+		{"bookmarks.json", len(bookmarks), exportBookmarks{Collections: nonNil(collections), Bookmarks: nonNil(bookmarks)}},
+	}
+	for _, f := range files {
+		entry, err := writeJSON(zw, f.name, f.data)
+		if err != nil {
+			return "", 0, err
+		}
+		entry.Records = f.records
+		m.Files = append(m.Files, entry)
+	}
+
+	if _, err := writeJSON(zw, "manifest.json", m); err != nil {
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", 0, err
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(e.dir, fmt.Sprintf("export-%d-%d.zip", export.ID, user.ID))
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) (manifestFile, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return manifestFile{}, err
+	}
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return manifestFile{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	return manifestFile{Name: name, Bytes: len(data), SHA256: hex.EncodeToString(sum[:])}, nil
+}
+
+// nonNil makes empty slices encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm/schema"
+)
+
+// The export rows are filled with Scan, which silently leaves fields at
+// zero when their column does not exist on the source table.
+func TestExportRowsMatchColumns(t *testing.T) {
+	cache := &sync.Map{}
+	parse := func(v interface{}) *schema.Schema {
+		s, err := schema.Parse(v, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parse %T: %v", v, err)
+		}
+		return s
+	}
+
+	cases := []struct {
+		row    interface{}
+		source interface{}
+	}{
+		{&exportPost{}, &models.Post{}},
+		{&exportComment{}, &models.Comment{}},
+		{&exportLike{}, &models.Like{}},
+		{&exportBookmark{}, &models.Bookmark{}},
+	}
+	for _, tc := range cases {
+		source := parse(tc.source)
+		for _, field := range parse(tc.row).Fields {
+			if source.LookUpField(field.DBName) == nil {
+				t.Errorf("%T.%s reads column %q, which %T does not have", tc.row, field.Name, field.DBName, tc.source)
+			}
+		}
+	}
+
+	post := parse(&exportPost{})
+	if got := post.LookUpField("Likes").DBName; got != "likes_count" {
+		t.Errorf("exportPost.Likes reads %q, want likes_count", got)
+	}
+	if got := post.LookUpField("Comments").DBName; got != "comments_count" {
+		t.Errorf("exportPost.Comments reads %q, want comments_count", got)
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// HotParams shape the hot score:
+//
+//	(likes*LikeWeight + comments*CommentWeight) / (age in hours + 2)^Gravity
+//
+// A higher Gravity makes posts fall off the hot list faster.
+type HotParams struct {
+	LikeWeight    float64
+	CommentWeight float64
+	Gravity       float64
+	// Posts older than MaxAge are no longer ranked and score zero.
+	MaxAge time.Duration
+}
+
+// HotRanker refreshes posts.hot_score, so sorting by hot is a plain indexed
+// read. Scores decay with time even without new engagement, which is why
+// they are recomputed on a timer rather than when a like lands.
+type HotRanker struct {
+	db       *gorm.DB
+	params   HotParams
+	interval time.Duration
+}
+
+func NewHotRanker(db *gorm.DB, params HotParams, interval time.Duration) *HotRanker {
+	return &HotRanker{db: db, params: params, interval: interval}
+}
+
+// Run refreshes the scores every interval until ctx is cancelled.
+func (r *HotRanker) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Refresh(ctx); err != nil {
+			log.Printf("ranker: failed to refresh hot scores: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the score of every published post inside MaxAge and
+// zeroes posts that have aged out. Only rows whose score changes are
+// written.
+func (r *HotRanker) Refresh(ctx context.Context) error {
+	db := r.db.WithContext(ctx)
+	cutoff := time.Now().Add(-r.params.MaxAge)
+
+	err := db.Exec(`
+		UPDATE posts SET hot_score = ranked.score
+		FROM (
+			SELECT id, (likes_count * ? + comments_count * ?)
+				/ power(GREATEST(EXTRACT(EPOCH FROM NOW() - published_at), 0) / 3600 + 2, ?) AS score
+			FROM posts
+			WHERE status = ? AND published_at >= ?
+		) ranked
+		WHERE posts.id = ranked.id AND posts.hot_score <> ranked.score`,
+		r.params.LikeWeight, r.params.CommentWeight, r.params.Gravity, models.StatusPublished, cutoff,
+	).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`
+		UPDATE posts SET hot_score = 0
+		WHERE hot_score <> 0 AND (status <> ? OR published_at IS NULL OR published_at < ?)`,
+		models.StatusPublished, cutoff,
+	).Error
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// RecommendParams bound the work of one recommendation run.
+type RecommendParams struct {
+	// Only likes this recent are considered.
+	Lookback time.Duration
+	// Two users, or two posts, need at least this many likes in common to
+	// count as similar.
+	MinOverlap int
+	// Results kept per user and per post.
+	Limit int
+}
+
+// Recommender rebuilds the user_suggestions and related_posts tables from
+// the likes table, so the endpoints that read them only do an indexed
+// lookup. Each table is replaced in one transaction; readers see either
+// the old or the new results.
+type Recommender struct {
+	db       *gorm.DB
+	params   RecommendParams
+	interval time.Duration
+}
+
+func NewRecommender(db *gorm.DB, params RecommendParams, interval time.Duration) *Recommender {
+	return &Recommender{db: db, params: params, interval: interval}
+}
+
+// Run rebuilds the recommendations every interval until ctx is cancelled.
+func (r *Recommender) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		if err := r.Rebuild(ctx); err != nil {
+			log.Printf("recommender: rebuild failed: %v", err)
+		} else {
+			log.Printf("recommender: rebuilt recommendations in %s", time.Since(start).Round(time.Millisecond))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Recommender) Rebuild(ctx context.Context) error {
+	if err := r.rebuildSuggestions(ctx); err != nil {
+		return err
+	}
+	return r.rebuildRelated(ctx)
+}
+
+// rebuildSuggestions scores authors for each user u: every user n who
+// shares at least MinOverlap liked posts with u is a neighbour weighted by
+// that overlap, and each like by a neighbour adds their weight to the
+// post's author. Authors u already follows are left out.
+func (r *Recommender) rebuildSuggestions(ctx context.Context) error {
+	since := time.Now().Add(-r.params.Lookback)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_suggestions").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			WITH recent AS (
+				SELECT user_id, post_id FROM likes WHERE created_at >= ?
+			),
+			neighbours AS (
+				SELECT a.user_id, b.user_id AS neighbour_id, COUNT(*) AS shared
+				FROM recent a
+				JOIN recent b ON b.post_id = a.post_id AND b.user_id <> a.user_id
+				GROUP BY a.user_id, b.user_id
+				HAVING COUNT(*) >= ?
+			),
+			scored AS (
+				SELECT n.user_id, p.user_id AS suggested_id, SUM(n.shared) AS score
+				FROM neighbours n
+				JOIN recent l ON l.user_id = n.neighbour_id
+				JOIN posts p ON p.id = l.post_id
+				WHERE p.user_id <> n.user_id
+					AND p.status = ? AND p.visibility = ? AND NOT p.hidden
+					AND NOT EXISTS (
+						SELECT 1 FROM follows f
+						WHERE f.follower_id = n.user_id AND f.following_id = p.user_id
+					)
+				GROUP BY n.user_id, p.user_id
+			),
+			ranked AS (
+				SELECT user_id, suggested_id, score,
+					ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, suggested_id) AS rank
+				FROM scored
+			)
+			INSERT INTO user_suggestions (user_id, suggested_id, score, created_at)
+			SELECT user_id, suggested_id, score, NOW() FROM ranked WHERE rank <= ?`,
+			since, r.params.MinOverlap, models.StatusPublished, models.VisibilityPublic, r.params.Limit,
+		).Error
+	})
+}
+
+// rebuildRelated pairs posts liked by the same users. The score is the
+// cosine similarity of their audiences, so a post everyone likes does not
+// become related to everything.
+func (r *Recommender) rebuildRelated(ctx context.Context) error {
+	since := time.Now().Add(-r.params.Lookback)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM related_posts").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			WITH recent AS (
+				SELECT l.user_id, l.post_id FROM likes l
+				JOIN posts p ON p.id = l.post_id
+				WHERE l.created_at >= ? AND p.status = ? AND NOT p.hidden
+			),
+			audience AS (
+				SELECT post_id, COUNT(*) AS size FROM recent GROUP BY post_id
+			),
+			pairs AS (
+				SELECT a.post_id, b.post_id AS related_id, COUNT(*) AS shared
+				FROM recent a
+				JOIN recent b ON b.user_id = a.user_id AND b.post_id <> a.post_id
+				GROUP BY a.post_id, b.post_id
+				HAVING COUNT(*) >= ?
+			),
+			ranked AS (
+				SELECT pairs.post_id, pairs.related_id,
+					pairs.shared / sqrt(pa.size::float8 * pb.size) AS score,
+					ROW_NUMBER() OVER (
+						PARTITION BY pairs.post_id
+						ORDER BY pairs.shared / sqrt(pa.size::float8 * pb.size) DESC, pairs.related_id DESC
+					) AS rank
+				FROM pairs
+				JOIN audience pa ON pa.post_id = pairs.post_id
+				JOIN audience pb ON pb.post_id = pairs.related_id
+			)
+			INSERT INTO related_posts (post_id, related_id, score, created_at)
+			SELECT post_id, related_id, score, NOW() FROM ranked WHERE rank <= ?`,
+			since, models.StatusPublished, r.params.MinOverlap, r.params.Limit,
+		).Error
+	})
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// PostScheduler publishes scheduled posts once their publish_at has passed.
+//
+// The state lives in the posts table, so a restart simply catches up on
+// whatever became due while the process was down. Each post is flipped by a
+// single conditional UPDATE, so it is published exactly once even when
+// several instances run the scheduler at the same time.
+type PostScheduler struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+func NewPostScheduler(db *gorm.DB, interval time.Duration) *PostScheduler {
+	return &PostScheduler{db: db, interval: interval}
+}
+
+// Run publishes due posts every interval until ctx is cancelled.
+func (s *PostScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.PublishDue(ctx); err != nil {
+			log.Printf("scheduler: failed to publish due posts: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes every scheduled post whose time has come and returns
+// the IDs this call published.
+func (s *PostScheduler) PublishDue(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Raw(`
+		UPDATE posts
+		SET status = ?, published_at = publish_at, live_at = NOW(), updated_at = NOW()
+		WHERE status = ? AND publish_at <= NOW()
+		RETURNING id`,
+		models.StatusPublished, models.StatusScheduled,
+	).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) > 0 {
+		log.Printf("scheduler: published %d scheduled posts", len(ids))
+	}
+	return ids, nil
+}
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/unfurl"
+	"gorm.io/gorm"
+)
+
+// stalePreviewAfter is how long a fetch may stay claimed before it is
+// assumed lost to a crash and retried. It is far beyond any fetch timeout.
+const stalePreviewAfter = 5 * time.Minute
+
+// Unfurler fills in queued link previews in the background.
+type Unfurler struct {
+	db       *gorm.DB
+	fetcher  unfurl.Fetcher
+	interval time.Duration
+}
+
+func NewUnfurler(db *gorm.DB, fetcher unfurl.Fetcher, interval time.Duration) *Unfurler {
+	return &Unfurler{db: db, fetcher: fetcher, interval: interval}
+}
+
+// Run fetches pending previews every interval until ctx is cancelled.
+func (u *Unfurler) Run(ctx context.Context) {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			preview, err := u.claim(ctx)
+			if err != nil {
+				log.Printf("unfurler: failed to claim preview: %v", err)
+				break
+			}
+			if preview == nil {
+				break
+			}
+			u.process(ctx, preview)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim marks the oldest pending (or abandoned) preview as running and
+// returns it. SKIP LOCKED keeps concurrent workers off the same row.
+func (u *Unfurler) claim(ctx context.Context) (*models.LinkPreview, error) {
+	var previews []models.LinkPreview
+	err := u.db.WithContext(ctx).Raw(`
+		UPDATE link_previews SET status = ?, started_at = NOW()
+		WHERE id = (
+			SELECT id FROM link_previews
+			WHERE status = ? OR (status = ? AND started_at < ?)
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		models.PreviewRunning, models.PreviewPending, models.PreviewRunning, time.Now().Add(-stalePreviewAfter),
+	).Scan(&previews).Error
+	if err != nil || len(previews) == 0 {
+		return nil, err
+	}
+	return &previews[0], nil
+}
+
+func (u *Unfurler) process(ctx context.Context, preview *models.LinkPreview) {
+	meta, err := u.fetcher.Fetch(ctx, preview.URL)
+	if err == nil && meta.Title == "" && meta.Description == "" {
+		// Nothing worth showing as a card
+		err = errors.New("page has no title or description")
+	}
+
+	updates := map[string]interface{}{"fetched_at": time.Now()}
+	if err != nil {
+		log.Printf("unfurler: preview %d for %s failed: %v", preview.ID, preview.URL, err)
+		updates["status"] = models.PreviewFailed
+	} else {
+		updates["status"] = models.PreviewReady
+		updates["title"] = meta.Title
+		updates["description"] = meta.Description
+		updates["image_url"] = meta.ImageURL
+		updates["site_name"] = meta.SiteName
+	}
+
+	err = u.db.Transaction(func(tx *gorm.DB) error {
+		// An edit may have queued a different link in the meantime; the
+		// newer request wins
+		result := tx.Model(&models.LinkPreview{}).
+			Where("id = ? AND url = ? AND status = ?", preview.ID, preview.URL, models.PreviewRunning).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 || updates["status"] != models.PreviewReady {
+			return result.Error
+		}
+		// The card changes the post response, so ETags and Last-Modified
+		// must move
+		return tx.Model(&models.Post{}).Where("id = ?", preview.PostID).
+			UpdateColumn("activity_at", gorm.Expr("NOW()")).Error
+	})
+	if err != nil {
+		log.Printf("unfurler: failed to record preview %d: %v", preview.ID, err)
+	}
+}
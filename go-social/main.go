@@ -1,29 +1,120 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/krisn2/go-social/audit"
 	"github.com/krisn2/go-social/config"
 	"github.com/krisn2/go-social/database"
+	"github.com/krisn2/go-social/grpcserver"
+	"github.com/krisn2/go-social/jobs"
 	"github.com/krisn2/go-social/routes"
+	"github.com/krisn2/go-social/unfurl"
+	"google.golang.org/grpc"
 )
 
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg.LogEffective()
 
 	// Initialize database
-	db, err := database.Initialize(cfg.DatabaseURL)
+	db, err := database.Initialize(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Audit events are written in the background
+	auditLog := audit.NewLogger(db)
+	go auditLog.Run()
+
 	// Setup routes
-	router := routes.Setup(db, cfg)
+	router := routes.Setup(db, cfg, auditLog)
+
+	server := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background jobs
+	go jobs.NewPostScheduler(db, cfg.Jobs.PublishInterval).Run(ctx)
+	go jobs.NewJanitor(db, cfg.Jobs.CleanupInterval).Run(ctx)
+	go jobs.NewExporter(db, cfg.Exports.Dir, cfg.Exports.TTL, cfg.Exports.PollInterval).Run(ctx)
+	go jobs.NewHotRanker(db, jobs.HotParams{
+		LikeWeight:    cfg.Ranking.LikeWeight,
+		CommentWeight: cfg.Ranking.CommentWeight,
+		Gravity:       cfg.Ranking.Gravity,
+		MaxAge:        cfg.Ranking.MaxAge,
+	}, cfg.Ranking.Interval).Run(ctx)
+	go jobs.NewRecommender(db, jobs.RecommendParams{
+		Lookback:   cfg.Recommend.Lookback,
+		MinOverlap: cfg.Recommend.MinOverlap,
+		Limit:      cfg.Recommend.Limit,
+	}, cfg.Recommend.Interval).Run(ctx)
+	if cfg.Unfurl.Enabled {
+		fetcher := unfurl.NewHTTPFetcher(cfg.Unfurl.Timeout, int64(cfg.Unfurl.MaxBytes), cfg.Unfurl.UserAgent, nil)
+		go jobs.NewUnfurler(db, fetcher, cfg.Unfurl.PollInterval).Run(ctx)
+	}
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// The gRPC API shares the service layer with the REST routes
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
+		if err != nil {
+			log.Fatalf("Failed to listen for gRPC: %v", err)
+		}
+		grpcServer = grpcserver.New(db, cfg.JWTSecret, cfg.GRPC.StreamPollInterval)
+		go func() {
+			log.Printf("gRPC server starting on port %s", cfg.GRPC.Port)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if grpcServer != nil {
+		go func() {
+			// Open streams never finish on their own
+			<-shutdownCtx.Done()
+			grpcServer.Stop()
+		}()
 	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	// Flush audit events from the requests that just finished
+	auditLog.Close()
 }
@@ -2,16 +2,20 @@ package middleware
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/krisn2/go-social/models"
 	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
 )
 
-func JWTAuth(jwtSecret string) gin.HandlerFunc {
+// JWTAuth requires a session JWT or a personal access token. Routes reached
+// with an access token still need RequireScope or RequireSession to say
+// what the token may do.
+func JWTAuth(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -19,44 +23,79 @@ func JWTAuth(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "bearer token required"})
+		if status, msg := authenticate(c, db, authHeader, jwtSecret); status != 0 {
+			c.AbortWithStatusJSON(status, gin.H{"error": msg})
 			return
 		}
+		c.Next()
+	}
+}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token cannot be empty"})
+// OptionalJWTAuth identifies the caller on public routes. Anonymous requests
+// pass through; a token that is present but invalid is still rejected so
+// clients notice expired sessions.
+func OptionalJWTAuth(jwtSecret string, db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &utils.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		if status, msg := authenticate(c, db, authHeader, jwtSecret); status != 0 {
+			c.AbortWithStatusJSON(status, gin.H{"error": msg})
 			return
 		}
+		c.Next()
+	}
+}
 
-		if !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token expired or invalid"})
-			return
-		}
+// authenticate identifies the caller from a bearer token and records them
+// on the context. It returns a zero status on success.
+func authenticate(c *gin.Context, db *gorm.DB, authHeader, jwtSecret string) (int, string) {
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return http.StatusUnauthorized, "bearer token required"
+	}
 
-		claims, ok := token.Claims.(*utils.Claims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			return
-		}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == "" {
+		return http.StatusUnauthorized, "token cannot be empty"
+	}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Next()
+	if utils.IsAccessToken(tokenString) {
+		return authenticateAccessToken(c, db, tokenString)
 	}
+
+	claims, err := utils.ParseToken(tokenString, jwtSecret)
+	if err != nil {
+		return http.StatusUnauthorized, "invalid token"
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	return 0, ""
+}
+
+func authenticateAccessToken(c *gin.Context, db *gorm.DB, tokenString string) (int, string) {
+	var token models.AccessToken
+	if err := db.Where("token_hash = ?", utils.HashAccessToken(tokenString)).First(&token).Error; err != nil {
+		return http.StatusUnauthorized, "invalid token"
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		return http.StatusUnauthorized, "token expired"
+	}
+
+	// Record use at most once a minute so busy tokens do not write on
+	// every request
+	db.Model(&models.AccessToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", token.ID, now.Add(-time.Minute)).
+		UpdateColumn("last_used_at", now)
+
+	c.Set("user_id", token.UserID)
+	c.Set("access_token", token)
+	return 0, ""
 }
 
 func GetUserID(c *gin.Context) (uint, error) {
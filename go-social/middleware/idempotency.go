@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+
+	// abandonedAfter is how long a key may stay in flight before it is
+	// assumed to belong to a request that died with its server.
+	abandonedAfter = time.Minute
+)
+
+// Idempotency makes a route safe to retry. A request carrying an
+// Idempotency-Key header is handled once per user and key; retries get the
+// stored response with Idempotent-Replayed: true, a retry with a different
+// body or path gets 422, and a retry that arrives while the first attempt
+// is still running gets 409. Server errors are not stored, so the request
+// can be retried. Requests without the header are handled normally. It
+// must run after JWTAuth.
+func Idempotency(db *gorm.DB, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: requestFingerprint(c.Request.Method, c.Request.URL.Path, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		claimed, existing, err := claimIdempotencyKey(db, &record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check Idempotency-Key"})
+			return
+		}
+		if !claimed {
+			switch {
+			case existing.Fingerprint != record.Fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.Status == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, existing.ContentType, existing.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			db.Delete(&record)
+			return
+		}
+		db.Model(&record).Updates(map[string]interface{}{
+			"status":       status,
+			"content_type": c.Writer.Header().Get("Content-Type"),
+			"response":     recorder.body.Bytes(),
+		})
+	}
+}
+
+// claimIdempotencyKey inserts record unless the user already used the key.
+// An expired or abandoned entry is replaced. When the key is taken, the
+// existing entry is returned.
+func claimIdempotencyKey(db *gorm.DB, record *models.IdempotencyKey) (bool, models.IdempotencyKey, error) {
+	var existing models.IdempotencyKey
+
+	for attempt := 0; attempt < 2; attempt++ {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return false, existing, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return true, existing, nil
+		}
+
+		if err := db.Where("user_id = ? AND key = ?", record.UserID, record.Key).First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue // removed in the meantime
+			}
+			return false, existing, err
+		}
+
+		now := time.Now()
+		stale := existing.ExpiresAt.Before(now) ||
+			(existing.Status == 0 && existing.CreatedAt.Before(now.Add(-abandonedAfter)))
+		if !stale {
+			return false, existing, nil
+		}
+
+		// Only one of several concurrent retries gets to remove it
+		if err := db.Where("id = ? AND created_at = ?", existing.ID, existing.CreatedAt).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return false, existing, err
+		}
+		record.ID = 0
+	}
+
+	// Lost the race twice to other retries; report it as in progress
+	return false, models.IdempotencyKey{Fingerprint: record.Fingerprint}, nil
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body for replay.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
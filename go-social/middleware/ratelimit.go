@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/config"
+)
+
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimit applies a token bucket per client IP.
+func RateLimit(cfg config.RateLimitConfig) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var (
+		mu        sync.Mutex
+		buckets   = make(map[string]*bucket)
+		lastSweep = time.Now()
+	)
+	rate := cfg.RequestsPerSecond
+	burst := float64(cfg.Burst)
+	idle := time.Duration(burst/rate*float64(time.Second)) + time.Minute
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		// Drop buckets that have refilled completely so the map stays bounded
+		if now.Sub(lastSweep) > idle {
+			for k, b := range buckets {
+				if now.Sub(b.lastSeen) > idle {
+					delete(buckets, k)
+				}
+			}
+			lastSweep = now
+		}
+
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{tokens: burst, lastSeen: now}
+			buckets[key] = b
+		}
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.lastSeen).Seconds()*rate)
+		b.lastSeen = now
+
+		allowed := b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+		wait := (1 - b.tokens) / rate
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// RejectSuspended refuses authenticated requests from accounts a moderator
+// has suspended. It must run after JWTAuth.
+func RejectSuspended(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		db.Select("id, suspended_until").First(&user, userID)
+		if user.SuspendedUntil != nil && user.SuspendedUntil.After(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":           "account suspended",
+				"suspended_until": user.SuspendedUntil,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxAccessTokens caps how many personal access tokens one user can hold.
+const MaxAccessTokens = 50
+
+const (
+	ScopeRead  = "read"  // every <area>:read scope
+	ScopeWrite = "write" // every <area>:write scope
+)
+
+// TokenScopes lists every scope a personal access token can be granted.
+var TokenScopes = []string{
+	ScopeRead, ScopeWrite,
+	"posts:read", "posts:write",
+	"users:read", "users:write",
+	"messages:read", "messages:write",
+	"communities:read", "communities:write",
+}
+
+// AccessToken is a personal access token, used in place of a session token
+// by scripts and integrations. Only a SHA-256 hash of the token is stored;
+// the token itself is shown once, when it is created.
+type AccessToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;size:64;not null"`
+	Hint       string     `json:"hint" gorm:"size:12;not null"` // leading characters, so users can tell tokens apart
+	Scopes     string     `json:"-" gorm:"size:500;not null"`   // space separated
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ScopeList returns the token's scopes.
+func (t AccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// Expired reports whether the token can no longer be used at now.
+func (t AccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Allows reports whether the token grants scope, either directly or
+// through the broad read or write scope.
+func (t AccessToken) Allows(scope string) bool {
+	action := scope[strings.LastIndex(scope, ":")+1:]
+	for _, granted := range t.ScopeList() {
+		if granted == scope || granted == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidScope reports whether scope is one of TokenScopes.
+func ValidScope(scope string) bool {
+	for _, s := range TokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+const (
+	AuditRegister            = "auth.register"
+	AuditLogin               = "auth.login"
+	AuditLoginFailed         = "auth.login_failed"
+	AuditReauthFailed        = "auth.reauth_failed" // wrong current password on a sensitive change
+	AuditPasswordChanged     = "user.password_changed"
+	AuditEmailChanged        = "user.email_changed"
+	AuditAccountDeleted      = "user.deleted"
+	AuditExportRequested     = "user.export_requested"
+	AuditExportDownloaded    = "user.export_downloaded"
+	AuditTokenCreated        = "user.token_created"
+	AuditTokenRevoked        = "user.token_revoked"
+	AuditIdentityLinked      = "user.identity_linked"
+	AuditIdentityUnlinked    = "user.identity_unlinked"
+	AuditListUsers           = "admin.list_users"
+	AuditListUsersDenied     = "admin.list_users_denied"
+	AuditSecurityEventsQuery = "admin.security_events_query"
+	AuditModerationPrefix    = "moderation." // followed by the moderation action
+)
+
+const TargetUser = "user"
+
+// AuditEvent is one entry in the append-only security audit log. The table
+// is protected by a trigger that rejects updates and deletes, and rows
+// outlive the accounts they mention.
+type AuditEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"` // nil for anonymous requests
+	Action     string    `json:"action" gorm:"size:50;not null;index"`
+	TargetType string    `json:"target_type,omitempty" gorm:"size:20;index:idx_audit_target"`
+	TargetID   *uint     `json:"target_id,omitempty" gorm:"index:idx_audit_target"`
+	IP         string    `json:"ip" gorm:"size:45"`
+	UserAgent  string    `json:"user_agent" gorm:"size:500"`
+	Details    string    `json:"details,omitempty" gorm:"size:500"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Block stops two users from interacting and hides their content from each
+// other in listings.
+type Block struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BlockerID uint      `json:"blocker_id" gorm:"index;not null"`
+	BlockedID uint      `json:"blocked_id" gorm:"index;not null"`
+	Blocked   User      `json:"-" gorm:"foreignKey:BlockedID"`
+	CreatedAt time.Time `json:"created_at"`
+}
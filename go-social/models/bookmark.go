@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Bookmark is a private save of a post. Each post can be bookmarked once per
+// user, optionally filed into one of the user's collections.
+type Bookmark struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"index;not null"`
+	PostID       uint      `json:"post_id" gorm:"index;not null"`
+	CollectionID *uint     `json:"collection_id" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type BookmarkCollection struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
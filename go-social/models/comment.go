@@ -5,9 +5,11 @@ import "time"
 type Comment struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Body      string    `json:"body" gorm:"type:text;not null"`
+	BodyHTML  string    `json:"body_html" gorm:"type:text"` // Body rendered by utils.RenderMarkdown
 	UserID    uint      `json:"user_id" gorm:"index;not null"`
 	User      User      `json:"author"`
 	PostID    uint      `json:"post_id" gorm:"index;not null"`
+	Hidden    bool      `json:"hidden" gorm:"not null;default:false"` // hidden by a moderator
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
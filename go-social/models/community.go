@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+const (
+	MembershipPublic  = "public"  // anyone can join and read
+	MembershipPrivate = "private" // joining needs approval; only members read
+)
+
+const (
+	CommunityRoleOwner     = "owner"
+	CommunityRoleModerator = "moderator"
+	CommunityRoleMember    = "member"
+)
+
+// Community groups posts around a topic. Posts with a CommunityID belong
+// to it; a private community's posts are visible to its members only.
+type Community struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:100"`
+	Slug        string    `json:"slug" gorm:"uniqueIndex;not null;size:50"`
+	Description string    `json:"description" gorm:"type:text"`
+	Membership  string    `json:"membership" gorm:"size:20;not null;default:public"`
+	CreatedByID uint      `json:"created_by_id" gorm:"index;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CommunityMember gives a user a role in a community. Every community has
+// exactly one owner.
+type CommunityMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CommunityID uint      `json:"community_id" gorm:"index;not null"`
+	UserID      uint      `json:"user_id" gorm:"index;not null"`
+	User        User      `json:"-"`
+	Role        string    `json:"role" gorm:"size:20;not null;default:member"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// JoinRequest asks to join a private community. It is deleted once a
+// moderator approves or rejects it.
+type JoinRequest struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CommunityID uint      `json:"community_id" gorm:"index;not null"`
+	UserID      uint      `json:"user_id" gorm:"index;not null"`
+	User        User      `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
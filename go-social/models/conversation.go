@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// MaxParticipants caps the size of a group conversation, creator included.
+const MaxParticipants = 10
+
+// Conversation is a private thread between two users or a small group.
+// ActivityAt is bumped by every message and orders the inbox.
+type Conversation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	IsGroup     bool      `json:"is_group" gorm:"not null;default:false"`
+	CreatedByID uint      `json:"created_by_id" gorm:"index;not null"`
+	ActivityAt  time.Time `json:"activity_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Participant puts a user in a conversation. LastReadMessageID is the
+// newest message the user has read; later messages count as unread and
+// other participants see it as a read receipt.
+type Participant struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	ConversationID    uint       `json:"conversation_id" gorm:"index;not null"`
+	UserID            uint       `json:"user_id" gorm:"index;not null"`
+	User              User       `json:"-"`
+	LastReadMessageID uint       `json:"last_read_message_id" gorm:"not null;default:0"`
+	LastReadAt        *time.Time `json:"last_read_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type Message struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ConversationID uint      `json:"conversation_id" gorm:"index;not null"`
+	SenderID       uint      `json:"sender_id" gorm:"index;not null"`
+	Sender         User      `json:"-" gorm:"foreignKey:SenderID"`
+	Body           string    `json:"body" gorm:"type:text;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	ExportPending = "pending"
+	ExportRunning = "running"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+	ExportExpired = "expired"
+)
+
+// DataExport tracks one request for a copy of a user's data. The archive is
+// built in the background and can be downloaded by its owner until
+// ExpiresAt.
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"-" gorm:"index;not null"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:pending;index"`
+	FilePath    string     `json:"-" gorm:"size:500"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Error       string     `json:"error,omitempty" gorm:"size:500"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Follow makes FollowerID a follower of FollowingID. Following a private
+// account creates a pending follow request, which grants nothing until the
+// account approves it.
+type Follow struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	FollowerID  uint      `json:"follower_id" gorm:"index;not null"`
+	Follower    User      `json:"-" gorm:"foreignKey:FollowerID"`
+	FollowingID uint      `json:"following_id" gorm:"index;not null"`
+	Following   User      `json:"-" gorm:"foreignKey:FollowingID"`
+	Pending     bool      `json:"pending" gorm:"not null;default:false"`
+	CreatedAt   time.Time `json:"created_at"`
+}
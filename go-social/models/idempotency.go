@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey remembers the outcome of a request sent with an
+// Idempotency-Key header so a retry can be answered with the same response.
+// Status is zero while the original request is still being handled.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null"`
+	Key         string `gorm:"size:255;not null"`
+	Fingerprint string `gorm:"size:64;not null"` // SHA-256 of method, path and body
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string `gorm:"size:100"`
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
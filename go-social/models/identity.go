@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Identity links an account to a user at an OpenID Connect provider. The
+// unique index on (provider, subject) keeps an external user on one
+// account.
+type Identity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"-" gorm:"index;not null"`
+	Provider    string     `json:"provider" gorm:"size:50;not null"`
+	Subject     string     `json:"-" gorm:"size:255;not null"`
+	Email       string     `json:"email" gorm:"size:255"` // as reported by the provider when linked
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCState carries a sign-in from the redirect to the provider to its
+// callback. It is deleted when the callback uses it, so each state works
+// once.
+type OIDCState struct {
+	ID           uint      `gorm:"primaryKey"`
+	State        string    `gorm:"uniqueIndex;size:64;not null"`
+	Provider     string    `gorm:"size:50;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"size:64;not null"`
+	BindingHash  string    `gorm:"size:64;not null"` // SHA-256 of the cookie set in the starting browser
+	LinkUserID   *uint     // set when a signed-in user is linking an identity
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	PreviewPending = "pending"
+	PreviewRunning = "running"
+	PreviewReady   = "ready"
+	PreviewFailed  = "failed"
+)
+
+// LinkPreview is the card unfurled in the background for the first link in
+// a post. It is reset to pending whenever an edit changes that link.
+type LinkPreview struct {
+	ID          uint       `json:"-" gorm:"primaryKey"`
+	PostID      uint       `json:"-" gorm:"uniqueIndex;not null"`
+	URL         string     `json:"url" gorm:"size:2048;not null"`
+	Status      string     `json:"-" gorm:"size:20;not null;default:pending;index"`
+	Title       string     `json:"title" gorm:"size:300"`
+	Description string     `json:"description" gorm:"size:1000"`
+	ImageURL    string     `json:"image_url" gorm:"size:2048"`
+	SiteName    string     `json:"site_name" gorm:"size:200"`
+	StartedAt   *time.Time `json:"-"`
+	FetchedAt   *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"-"`
+	UpdatedAt   time.Time  `json:"-"`
+}
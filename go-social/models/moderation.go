@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	ActionDismiss = "dismiss"
+	ActionHide    = "hide"
+	ActionUnhide  = "unhide"
+	ActionDelete  = "delete"
+	ActionWarn    = "warn"
+	ActionSuspend = "suspend"
+)
+
+// ModerationAction is the append-only audit trail of moderator decisions.
+// Rows are never updated or deleted.
+type ModerationAction struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ModeratorID   uint      `json:"moderator_id" gorm:"index;not null"`
+	Action        string    `json:"action" gorm:"size:20;not null"`
+	TargetType    string    `json:"target_type" gorm:"size:20;not null;index:idx_moderation_target"`
+	TargetID      uint      `json:"target_id" gorm:"not null;index:idx_moderation_target"`
+	SubjectUserID uint      `json:"subject_user_id" gorm:"index"` // author of the moderated content
+	Note          string    `json:"note" gorm:"size:1000"`
+	ReportsClosed int       `json:"reports_closed"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// Warning is a moderator's warning to the author of moderated content. The
+// author sees it among their warnings until they mark it read.
+type Warning struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"index;not null"`
+	TargetType string     `json:"target_type" gorm:"size:20;not null"`
+	TargetID   uint       `json:"target_id" gorm:"not null"`
+	Note       string     `json:"note" gorm:"size:1000;not null"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
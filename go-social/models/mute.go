@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Mute hides the muted user's content from the muter only.
+type Mute struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MuterID   uint      `json:"muter_id" gorm:"index;not null"`
+	MutedID   uint      `json:"muted_id" gorm:"index;not null"`
+	Muted     User      `json:"-" gorm:"foreignKey:MutedID"`
+	CreatedAt time.Time `json:"created_at"`
+}
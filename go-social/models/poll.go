@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 6
+)
+
+// Poll is attached to a post at creation. Each user casts one ballot,
+// naming one option or, for multiple-choice polls, several.
+type Poll struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	PostID         uint         `json:"post_id" gorm:"uniqueIndex;not null"`
+	MultipleChoice bool         `json:"multiple_choice" gorm:"not null;default:false"`
+	ClosesAt       time.Time    `json:"closes_at" gorm:"not null"`
+	VotersCount    int          `json:"voters_count" gorm:"not null;default:0"` // ballots cast, kept with the option counters
+	Options        []PollOption `json:"options" gorm:"foreignKey:PollID"`
+	MyChoices      []uint       `json:"-" gorm:"-"` // option IDs the viewer voted for, set by the service
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// Closed reports whether voting has ended at now.
+func (p Poll) Closed(now time.Time) bool {
+	return !now.Before(p.ClosesAt)
+}
+
+type PollOption struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	PollID     uint   `json:"poll_id" gorm:"index;not null"`
+	Position   int    `json:"position" gorm:"not null"`
+	Text       string `json:"text" gorm:"not null;size:100"`
+	VotesCount int    `json:"votes_count" gorm:"not null;default:0"`
+}
+
+// PollBallot records that a user voted in a poll. Its unique index on
+// (poll_id, user_id) is what stops anyone voting twice.
+type PollBallot struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PollID    uint      `json:"poll_id" gorm:"not null"`
+	UserID    uint      `json:"user_id" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PollVote is one option chosen on a ballot.
+type PollVote struct {
+	ID       uint `json:"id" gorm:"primaryKey"`
+	BallotID uint `json:"ballot_id" gorm:"not null"`
+	OptionID uint `json:"option_id" gorm:"index;not null"`
+}
@@ -2,14 +2,50 @@ package models
 
 import "time"
 
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityUnlisted  = "unlisted" // reachable by link, left out of listings
+	VisibilityPrivate   = "private"
+)
+
 type Post struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title" gorm:"not null;size:200;index"` // Add index for search
-	Body      string    `json:"body" gorm:"type:text"`
-	UserID    uint      `json:"user_id" gorm:"index;not null"`
-	User      User      `json:"author"`
-	Likes     []Like    `json:"-"`
-	Comments  []Comment `json:"-"`
-	CreatedAt time.Time `json:"created_at" gorm:"index"` // Add index for sorting
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Title       string     `json:"title" gorm:"not null;size:200;index"` // Add index for search
+	Body        string     `json:"body" gorm:"type:text"`
+	BodyHTML    string     `json:"body_html" gorm:"type:text"` // Body rendered by utils.RenderMarkdown
+	UserID      uint       `json:"user_id" gorm:"index;not null"`
+	Hidden      bool       `json:"hidden" gorm:"not null;default:false"` // hidden by a moderator
+	Status      string     `json:"status" gorm:"size:20;not null;default:published;index"`
+	PublishAt   *time.Time `json:"publish_at" gorm:"index"`   // when a scheduled post goes live
+	PublishedAt *time.Time `json:"published_at" gorm:"index"` // nil until published
+	LiveAt      *time.Time `json:"-" gorm:"index"`            // when the post became visible; scheduled posts keep publish_at as published_at
+	Visibility  string     `json:"visibility" gorm:"size:20;not null;default:public"`
+	CommunityID *uint      `json:"community_id" gorm:"index"` // nil for posts outside any community
+	RepostOfID  *uint      `json:"repost_of_id" gorm:"index"` // set on plain reposts, which have no content of their own
+	QuoteOfID   *uint      `json:"quote_of_id" gorm:"index"`  // set on quote posts; kept after the original is deleted
+	// Denormalized counters, kept exact in the same transaction as the
+	// like, comment or repost change
+	LikesCount    int          `json:"likes_count" gorm:"not null;default:0"`
+	CommentsCount int          `json:"comments_count" gorm:"not null;default:0"`
+	RepostCount   int          `json:"repost_count" gorm:"not null;default:0"`      // plain reposts and quotes
+	HotScore      float64      `json:"-" gorm:"not null;default:0"`                 // time-decayed engagement, refreshed by jobs.HotRanker
+	ActivityAt    time.Time    `json:"-" gorm:"not null;default:CURRENT_TIMESTAMP"` // last like, comment or link preview change, for Last-Modified
+	User          User         `json:"author"`
+	LinkPreview   *LinkPreview `json:"link_preview" gorm:"-"` // set by the service when a ready preview exists
+	Poll          *Poll        `json:"poll" gorm:"-"`         // set by the service when the post has a poll
+	// The post a repost or quote refers to, set by the service. It stays nil
+	// when the viewer cannot see it; OriginalDeleted tells when it no longer exists.
+	Original        *Post     `json:"-" gorm:"-"`
+	OriginalDeleted bool      `json:"-" gorm:"-"`
+	Likes           []Like    `json:"-"`
+	Comments        []Comment `json:"-"`
+	CreatedAt       time.Time `json:"created_at" gorm:"index"` // Add index for sorting
+	UpdatedAt       time.Time `json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UserSuggestion is a precomputed "who to follow" entry. The table is
+// rebuilt wholesale by jobs.Recommender.
+type UserSuggestion struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	UserID      uint      `json:"-" gorm:"not null"`
+	SuggestedID uint      `json:"-" gorm:"index;not null"`
+	Score       float64   `json:"-" gorm:"not null"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// RelatedPost links a post to one liked by the same audience. Like
+// UserSuggestion it is rebuilt by jobs.Recommender.
+type RelatedPost struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	PostID    uint      `json:"-" gorm:"not null"`
+	RelatedID uint      `json:"-" gorm:"index;not null"`
+	Score     float64   `json:"-" gorm:"not null"`
+	CreatedAt time.Time `json:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+const (
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// ReportReasons are the categories a reporter can choose from.
+var ReportReasons = []string{"spam", "harassment", "hate", "violence", "sexual", "misinformation", "other"}
+
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	ReporterID uint       `json:"reporter_id" gorm:"index;not null"`
+	Reporter   User       `json:"reporter" gorm:"foreignKey:ReporterID"`
+	TargetType string     `json:"target_type" gorm:"size:20;not null;index:idx_reports_target"`
+	TargetID   uint       `json:"target_id" gorm:"not null;index:idx_reports_target"`
+	Reason     string     `json:"reason" gorm:"size:30;not null"`
+	Details    string     `json:"details" gorm:"size:1000"`
+	Status     string     `json:"status" gorm:"size:20;not null;default:open;index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
@@ -4,12 +4,39 @@ import (
 	"time"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	CommentsEveryone  = "everyone"
+	CommentsFollowers = "followers"
+	CommentsNobody    = "nobody"
+)
+
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null;size:100"`
-	Email     string    `json:"email" gorm:"uniqueIndex;not null;size:255"`
-	Password  string    `json:"-" gorm:"not null"` // bcrypt hash
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Posts     []Post    `json:"-"`
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	Name            string          `json:"name" gorm:"not null;size:100"`
+	Email           string          `json:"email" gorm:"uniqueIndex;not null;size:255"`
+	Password        string          `json:"-" gorm:"not null"`                 // bcrypt hash
+	EmailVerifiedAt *time.Time      `json:"-"`                                 // set when an OpenID Connect provider vouched for Email
+	Handle          *string         `json:"handle" gorm:"uniqueIndex;size:30"` // nil until the user picks one
+	Bio             string          `json:"bio" gorm:"size:500"`
+	AvatarURL       string          `json:"avatar_url" gorm:"size:500"`
+	Role            string          `json:"role" gorm:"size:20;not null;default:user"`
+	Privacy         PrivacySettings `json:"privacy" gorm:"embedded;embeddedPrefix:privacy_"`
+	SuspendedUntil  *time.Time      `json:"suspended_until"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Posts           []Post          `json:"-"`
+}
+
+// PrivacySettings controls what other users can see of an account and how
+// they can interact with it.
+type PrivacySettings struct {
+	PrivateAccount bool   `json:"private_account" gorm:"not null;default:false"` // hide profile details and activity
+	HideLikes      bool   `json:"hide_likes" gorm:"not null;default:false"`      // hide the list of liked posts
+	WhoCanComment  string `json:"who_can_comment" gorm:"size:20;not null;default:everyone"`
 }
@@ -0,0 +1,210 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/oidc/oidctest"
+)
+
+const redirectURL = "http://app.test/api/auth/oidc/mock/callback"
+
+type mock struct {
+	issuer    *oidctest.Issuer
+	provider  *Provider
+	discovery atomic.Int32
+	jwks      atomic.Int32
+}
+
+// newMock serves a mock issuer and returns a provider configured for it.
+// Requests for discovery and keys are counted.
+func newMock(t *testing.T) *mock {
+	t.Helper()
+	m := &mock{}
+	var h http.Handler
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			m.discovery.Add(1)
+		case "/jwks":
+			m.jwks.Add(1)
+		}
+		h.ServeHTTP(w, r)
+	}))
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	iss, err := oidctest.New(srv.URL, "go-social", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = iss.Handler()
+
+	m.issuer = iss
+	m.provider = New(config.OIDCProvider{
+		Name:         "mock",
+		Issuer:       srv.URL,
+		ClientID:     "go-social",
+		ClientSecret: "secret",
+	}, redirectURL, srv.Client())
+	return m
+}
+
+// authorize follows the authorization URL and returns the code the issuer
+// redirects back with.
+func (m *mock) authorize(t *testing.T, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := m.provider.AuthCodeURL(context.Background(), "state", nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loc.Query().Get("state"); got != "state" {
+		t.Fatalf("state = %q, want %q", got, "state")
+	}
+	return loc.Query().Get("code")
+}
+
+func TestExchange(t *testing.T) {
+	m := newMock(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		code := m.authorize(t, "nonce", "verifier")
+		claims, err := m.provider.Exchange(ctx, code, "verifier", "nonce")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.Email != "alice@example.com" || !claims.EmailVerified || claims.Subject == "" {
+			t.Errorf("claims = %+v", claims)
+		}
+	}
+
+	if n := m.discovery.Load(); n != 1 {
+		t.Errorf("discovery fetched %d times, want 1", n)
+	}
+	if n := m.jwks.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	m := newMock(t)
+	ctx := context.Background()
+
+	code := m.authorize(t, "nonce", "verifier")
+	if _, err := m.provider.Exchange(ctx, code, "verifier", "nonce"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.provider.Exchange(ctx, code, "verifier", "nonce"); err == nil {
+		t.Error("second Exchange of the same code succeeded")
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	cases := []struct {
+		name     string
+		verifier string
+		nonce    string
+		claims   func(jwt.MapClaims)
+		want     string
+	}{
+		{
+			name:     "PKCE verifier mismatch",
+			verifier: "other-verifier",
+			want:     "invalid_grant",
+		},
+		{
+			name:  "nonce mismatch",
+			nonce: "other-nonce",
+			want:  "nonce does not match",
+		},
+		{
+			name:   "audience of another client",
+			claims: func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+			want:   "invalid id_token",
+		},
+		{
+			name:   "several audiences without azp",
+			claims: func(c jwt.MapClaims) { c["aud"] = []string{"go-social", "someone-else"} },
+			want:   "issued to another client",
+		},
+		{
+			name: "several audiences authorized for another client",
+			claims: func(c jwt.MapClaims) {
+				c["aud"] = []string{"go-social", "someone-else"}
+				c["azp"] = "someone-else"
+			},
+			want: "issued to another client",
+		},
+		{
+			name:   "another issuer",
+			claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+			want:   "invalid id_token",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMock(t)
+			m.issuer.OnToken(tc.claims)
+
+			verifier, nonce := "verifier", "nonce"
+			code := m.authorize(t, nonce, verifier)
+			if tc.verifier != "" {
+				verifier = tc.verifier
+			}
+			if tc.nonce != "" {
+				nonce = tc.nonce
+			}
+
+			_, err := m.provider.Exchange(context.Background(), code, verifier, nonce)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestExchangeAcceptsAuthorizedParty(t *testing.T) {
+	m := newMock(t)
+	m.issuer.OnToken(func(c jwt.MapClaims) {
+		c["aud"] = []string{"go-social", "someone-else"}
+		c["azp"] = "go-social"
+	})
+
+	code := m.authorize(t, "nonce", "verifier")
+	if _, err := m.provider.Exchange(context.Background(), code, "verifier", "nonce"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	m := newMock(t)
+	m.issuer.URL = "https://elsewhere.test"
+
+	if _, err := m.provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Error("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
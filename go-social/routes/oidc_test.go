@@ -0,0 +1,275 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
+	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/database"
+	"github.com/krisn2/go-social/oidc/oidctest"
+	"gorm.io/gorm/logger"
+)
+
+// oidcFlow drives the sign-in flow through the router against the mock
+// issuer. The tests need a database:
+//
+//	DATABASE_URL=postgres://... go test -run OIDC ./routes
+type oidcFlow struct {
+	t      *testing.T
+	router *gin.Engine
+	issuer *oidctest.Issuer
+}
+
+func newOIDCFlow(t *testing.T) *oidcFlow {
+	t.Helper()
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+
+	var issuer http.Handler
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		issuer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	iss, err := oidctest.New(srv.URL, "go-social", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer = iss.Handler()
+
+	cfg := config.Default()
+	cfg.DatabaseURL = dsn
+	cfg.LogLevel = "error"
+	cfg.RateLimit.Enabled = false
+	cfg.OIDC.RedirectURL = "http://app.test/api/auth/oidc"
+	cfg.OIDC.Providers = []config.OIDCProvider{{
+		Name:         "mock",
+		Issuer:       srv.URL,
+		ClientID:     "go-social",
+		ClientSecret: "secret",
+	}}
+
+	db, err := database.Initialize(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Logger = logger.Discard
+	auditLog := audit.NewLogger(db)
+	go auditLog.Run()
+	t.Cleanup(auditLog.Close)
+
+	gin.SetMode(gin.TestMode)
+	return &oidcFlow{t: t, router: Setup(db, cfg, auditLog), issuer: iss}
+}
+
+func uniqueEmail(name string) string {
+	return fmt.Sprintf("%s-%d@example.com", name, time.Now().UnixNano())
+}
+
+func (f *oidcFlow) do(method, path, token string, body any, cookies ...*http.Cookie) (*httptest.ResponseRecorder, map[string]any) {
+	f.t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// start begins a sign-in, or a link when token is set, and returns the
+// callback path the issuer redirects to for email together with the
+// binding cookie.
+func (f *oidcFlow) start(email, token string) (string, *http.Cookie) {
+	f.t.Helper()
+	path := "/api/auth/oidc/mock/start"
+	if token != "" {
+		path = "/api/users/me/identities/mock"
+	}
+	w, resp := f.do(http.MethodPost, path, token, nil)
+	if w.Code != http.StatusOK {
+		f.t.Fatalf("start returned %d: %s", w.Code, w.Body)
+	}
+
+	var binding *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "oidc_binding" {
+			binding = cookie
+		}
+	}
+	if binding == nil {
+		f.t.Fatal("start set no binding cookie")
+	}
+	if binding.Path != "/api/auth/oidc" || !binding.HttpOnly {
+		f.t.Errorf("binding cookie path %q, HttpOnly %v", binding.Path, binding.HttpOnly)
+	}
+
+	authURL, _ := resp["authorization_url"].(string)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL + "&login_hint=" + url.QueryEscape(email))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	res.Body.Close()
+	callback, err := url.Parse(res.Header.Get("Location"))
+	if err != nil || res.StatusCode != http.StatusFound {
+		f.t.Fatalf("authorize returned %d, Location %q", res.StatusCode, res.Header.Get("Location"))
+	}
+	return callback.RequestURI(), &http.Cookie{Name: binding.Name, Value: binding.Value}
+}
+
+// signIn completes a sign-in for email and returns the response.
+func (f *oidcFlow) signIn(email string) (*httptest.ResponseRecorder, map[string]any) {
+	f.t.Helper()
+	callback, binding := f.start(email, "")
+	return f.do(http.MethodGet, callback, "", nil, binding)
+}
+
+func TestOIDCCallbackRequiresBindingCookie(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := uniqueEmail("binding")
+
+	callback, _ := f.start(email, "")
+	if w, _ := f.do(http.MethodGet, callback, "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("callback without cookie returned %d, want 400", w.Code)
+	}
+
+	callback, _ = f.start(email, "")
+	_, other := f.start(email, "")
+	if w, _ := f.do(http.MethodGet, callback, "", nil, other); w.Code != http.StatusBadRequest {
+		t.Errorf("callback with another flow's cookie returned %d, want 400", w.Code)
+	}
+
+	// The state is spent either way
+	if w, _ := f.do(http.MethodGet, callback, "", nil, other); w.Code != http.StatusBadRequest {
+		t.Errorf("replayed callback returned %d, want 400", w.Code)
+	}
+}
+
+func TestOIDCSignInCreatesAccount(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := uniqueEmail("new")
+
+	w, resp := f.signIn(email)
+	if w.Code != http.StatusCreated || resp["created"] != true || resp["token"] == "" {
+		t.Fatalf("first sign-in returned %d: %s", w.Code, w.Body)
+	}
+	user, _ := resp["user"].(map[string]any)
+	if user["email"] != email {
+		t.Errorf("user email = %v, want %s", user["email"], email)
+	}
+
+	w, resp = f.signIn(email)
+	if w.Code != http.StatusOK || resp["created"] != false {
+		t.Fatalf("second sign-in returned %d: %s", w.Code, w.Body)
+	}
+	if again, _ := resp["user"].(map[string]any); again["id"] != user["id"] {
+		t.Errorf("second sign-in reached user %v, want %v", again["id"], user["id"])
+	}
+}
+
+func TestOIDCSignInRejectsUnverifiedEmail(t *testing.T) {
+	f := newOIDCFlow(t)
+	f.issuer.EmailVerified = false
+
+	if w, _ := f.signIn(uniqueEmail("unverified")); w.Code != http.StatusForbidden {
+		t.Errorf("sign-in with unverified email returned %d, want 403", w.Code)
+	}
+}
+
+func TestOIDCSignInRefusesUnverifiedPasswordAccount(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := uniqueEmail("password")
+
+	w, _ := f.do(http.MethodPost, "/api/auth/register", "", gin.H{"name": "Pat", "email": email, "password": "secret123"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register returned %d: %s", w.Code, w.Body)
+	}
+
+	if w, _ := f.signIn(email); w.Code != http.StatusConflict {
+		t.Errorf("sign-in for a password account returned %d, want 409", w.Code)
+	}
+}
+
+func TestOIDCLinkAndUnlink(t *testing.T) {
+	f := newOIDCFlow(t)
+
+	w, resp := f.signIn(uniqueEmail("owner"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("sign-in returned %d: %s", w.Code, w.Body)
+	}
+	token, _ := resp["token"].(string)
+
+	// A second identity, from another address at the provider
+	callback, binding := f.start(uniqueEmail("second"), token)
+	w, linked := f.do(http.MethodGet, callback, "", nil, binding)
+	if w.Code != http.StatusCreated || linked["provider"] != "mock" {
+		t.Fatalf("link returned %d: %s", w.Code, w.Body)
+	}
+
+	w, resp = f.do(http.MethodGet, "/api/users/me/identities", token, nil)
+	data, _ := resp["data"].([]any)
+	if w.Code != http.StatusOK || len(data) != 2 {
+		t.Fatalf("identities returned %d: %s", w.Code, w.Body)
+	}
+	first, _ := data[0].(map[string]any)
+
+	if w, _ := f.do(http.MethodDelete, fmt.Sprintf("/api/users/me/identities/%.0f", linked["id"]), token, nil); w.Code != http.StatusOK {
+		t.Fatalf("unlink returned %d: %s", w.Code, w.Body)
+	}
+
+	// The account has no password, so its last identity stays
+	if w, _ := f.do(http.MethodDelete, fmt.Sprintf("/api/users/me/identities/%.0f", first["id"]), token, nil); w.Code != http.StatusConflict {
+		t.Errorf("unlinking the last identity returned %d, want 409", w.Code)
+	}
+}
+
+func TestOIDCUnlinkWithPassword(t *testing.T) {
+	f := newOIDCFlow(t)
+	email := uniqueEmail("linked")
+
+	w, resp := f.do(http.MethodPost, "/api/auth/register", "", gin.H{"name": "Pat", "email": email, "password": "secret123"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register returned %d: %s", w.Code, w.Body)
+	}
+	token, _ := resp["token"].(string)
+
+	// Linking from a session works where signing in by email did not
+	callback, binding := f.start(email, token)
+	w, linked := f.do(http.MethodGet, callback, "", nil, binding)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("link returned %d: %s", w.Code, w.Body)
+	}
+
+	if w, _ := f.signIn(email); w.Code != http.StatusOK {
+		t.Errorf("sign-in through the linked identity returned %d, want 200", w.Code)
+	}
+
+	// The password still signs in, so the only identity may go
+	if w, _ := f.do(http.MethodDelete, fmt.Sprintf("/api/users/me/identities/%.0f", linked["id"]), token, nil); w.Code != http.StatusOK {
+		t.Errorf("unlink returned %d: %s", w.Code, w.Body)
+	}
+}
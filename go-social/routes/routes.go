@@ -2,22 +2,59 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/audit"
 	"github.com/krisn2/go-social/config"
+	"github.com/krisn2/go-social/gql"
 	"github.com/krisn2/go-social/handlers"
 	"github.com/krisn2/go-social/middleware"
 	"gorm.io/gorm"
 )
 
-func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
+func Setup(db *gorm.DB, cfg *config.Config, auditLog *audit.Logger) *gin.Engine {
+	if cfg.IsProduction() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
 	router := gin.Default()
+	// The rate limiter and the audit log key on ClientIP, so only configured
+	// proxies may set X-Forwarded-For. Validate has checked the list; fail
+	// closed if it is somehow unusable.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(gin.Recovery())
+	router.Use(middleware.CORS(cfg.CORS))
+	router.Use(middleware.RateLimit(cfg.RateLimit))
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(db, cfg)
-	userHandler := handlers.NewUserHandler(db, cfg)
+	authHandler := handlers.NewAuthHandler(db, cfg, auditLog)
+	userHandler := handlers.NewUserHandler(db, cfg, auditLog)
 	postHandler := handlers.NewPostHandler(db)
 	likeHandler := handlers.NewLikeHandler(db)
 	commentHandler := handlers.NewCommentHandler(db)
+	blockHandler := handlers.NewBlockHandler(db)
+	reportHandler := handlers.NewReportHandler(db)
+	moderationHandler := handlers.NewModerationHandler(db, auditLog)
+	bookmarkHandler := handlers.NewBookmarkHandler(db)
+	followHandler := handlers.NewFollowHandler(db)
+	messageHandler := handlers.NewMessageHandler(db)
+	communityHandler := handlers.NewCommunityHandler(db)
+	exportHandler := handlers.NewExportHandler(db, auditLog)
+	auditHandler := handlers.NewAuditHandler(db, auditLog)
+	tokenHandler := handlers.NewTokenHandler(db, auditLog)
+	oidcHandler := handlers.NewOIDCHandler(db, cfg, auditLog)
+	graphQLHandler := gql.NewHandler(db)
+
+	// Lets clients retry create requests without creating duplicates
+	idempotent := middleware.Idempotency(db, cfg.Idempotency.TTL)
+
+	// Personal access tokens reach a route only with its area's read or
+	// write scope; session-only routes manage the account itself
+	postsScope := middleware.RequireScope("posts")
+	usersScope := middleware.RequireScope("users")
+	messagesScope := middleware.RequireScope("messages")
+	communitiesScope := middleware.RequireScope("communities")
+	sessionOnly := middleware.RequireSession
 
 	api := router.Group("/api")
 	{
@@ -26,48 +63,164 @@ func Setup(db *gorm.DB, cfg *config.Config) *gin.Engine {
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+
+			// OpenID Connect sign-in; the provider redirects the user's
+			// browser to the callback
+			auth.GET("/oidc", oidcHandler.Providers)
+			auth.POST("/oidc/:provider/start", oidcHandler.Start)
+			auth.GET("/oidc/:provider/callback", oidcHandler.Callback)
 		}
 
-		// Public user routes
+		// GraphQL serves anonymous and signed-in callers alike; the resolvers
+		// apply the same rules as the REST routes, including token scopes
+		api.POST("/graphql", middleware.OptionalJWTAuth(cfg.JWTSecret, db), middleware.RejectSuspended(db), graphQLHandler.Serve)
+
+		// Public user routes, optionally authenticated so privacy rules know
+		// who is asking
 		users := api.Group("/users")
+		users.Use(middleware.OptionalJWTAuth(cfg.JWTSecret, db))
 		{
-			users.GET("/", userHandler.ListUsers) // Admin gated
+			users.GET("/", sessionOnly, userHandler.ListUsers) // Admin gated
+			users.GET("/:id", usersScope, userHandler.GetProfile)
+			users.GET("/:id/posts", postsScope, postHandler.ListByUser)
+			users.GET("/:id/comments", postsScope, commentHandler.ListByUser)
+			users.GET("/:id/likes", postsScope, postHandler.ListLikedByUser)
+			users.GET("/:id/followers", usersScope, followHandler.Followers)
+			users.GET("/:id/following", usersScope, followHandler.Following)
 		}
 
-		// Public post routes
+		// Public post routes, optionally authenticated so visibility rules
+		// know who is asking
 		posts := api.Group("/posts")
+		posts.Use(middleware.OptionalJWTAuth(cfg.JWTSecret, db))
 		{
-			posts.GET("/", postHandler.List)
-			posts.GET("/:id", postHandler.Get)
-			posts.GET("/:id/comments", commentHandler.List)
+			posts.GET("/", postsScope, postHandler.List)
+			posts.GET("/:id", postsScope, postHandler.Get)
+			posts.GET("/:id/comments", postsScope, commentHandler.List)
+			posts.GET("/:id/related", postsScope, postHandler.ListRelated)
+		}
+
+		// Public community routes, optionally authenticated so private
+		// communities know whether the caller is a member
+		communities := api.Group("/communities")
+		communities.Use(middleware.OptionalJWTAuth(cfg.JWTSecret, db))
+		{
+			communities.GET("/", communitiesScope, communityHandler.List)
+			communities.GET("/:slug", communitiesScope, communityHandler.Get)
+			communities.GET("/:slug/posts", postsScope, postHandler.ListByCommunity)
+			communities.GET("/:slug/members", communitiesScope, communityHandler.Members)
 		}
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.JWTAuth(cfg.JWTSecret))
+		protected.Use(middleware.JWTAuth(cfg.JWTSecret, db), middleware.RejectSuspended(db))
 		{
 			// User routes
 			protectedUsers := protected.Group("/users")
 			{
-				protectedUsers.GET("/me", userHandler.GetMe)
-				protectedUsers.PATCH("/me", userHandler.UpdateMe)
-				protectedUsers.DELETE("/me", userHandler.DeleteMe)
+				protectedUsers.GET("/me", usersScope, userHandler.GetMe)
+				protectedUsers.PATCH("/me", usersScope, userHandler.UpdateMe)
+				protectedUsers.DELETE("/me", sessionOnly, userHandler.DeleteMe)
+				protectedUsers.PATCH("/me/credentials", sessionOnly, userHandler.UpdateCredentials)
+				protectedUsers.GET("/me/security-events", sessionOnly, auditHandler.Mine)
+				protectedUsers.GET("/me/privacy", usersScope, userHandler.GetPrivacy)
+				protectedUsers.PATCH("/me/privacy", usersScope, userHandler.UpdatePrivacy)
+				protectedUsers.GET("/me/blocks", usersScope, blockHandler.ListBlocks)
+				protectedUsers.GET("/me/mutes", usersScope, blockHandler.ListMutes)
+				protectedUsers.GET("/me/bookmarks", postsScope, postHandler.ListBookmarks)
+				protectedUsers.GET("/me/drafts", postsScope, postHandler.ListDrafts)
+				protectedUsers.GET("/me/suggestions", usersScope, followHandler.Suggestions)
+				protectedUsers.GET("/me/warnings", usersScope, moderationHandler.Warnings)
+				protectedUsers.POST("/me/warnings/:id/read", usersScope, moderationHandler.ReadWarning)
+				protectedUsers.GET("/me/follow-requests", usersScope, followHandler.FollowRequests)
+				protectedUsers.POST("/me/followers/:id", usersScope, followHandler.ApproveFollower)
+				protectedUsers.DELETE("/me/followers/:id", usersScope, followHandler.RemoveFollower)
+				protectedUsers.GET("/me/bookmarks/collections", postsScope, bookmarkHandler.ListCollections)
+				protectedUsers.POST("/me/bookmarks/collections", postsScope, bookmarkHandler.CreateCollection)
+				protectedUsers.PATCH("/me/bookmarks/collections/:id", postsScope, bookmarkHandler.RenameCollection)
+				protectedUsers.DELETE("/me/bookmarks/collections/:id", postsScope, bookmarkHandler.DeleteCollection)
+				protectedUsers.POST("/me/export", sessionOnly, exportHandler.Request)
+				protectedUsers.GET("/me/exports", sessionOnly, exportHandler.List)
+				protectedUsers.GET("/me/exports/:id", sessionOnly, exportHandler.Get)
+				protectedUsers.GET("/me/exports/:id/download", sessionOnly, exportHandler.Download)
+				protectedUsers.GET("/me/tokens", sessionOnly, tokenHandler.List)
+				protectedUsers.POST("/me/tokens", sessionOnly, tokenHandler.Create)
+				protectedUsers.DELETE("/me/tokens/:id", sessionOnly, tokenHandler.Revoke)
+				protectedUsers.GET("/me/identities", sessionOnly, oidcHandler.ListIdentities)
+				protectedUsers.POST("/me/identities/:provider", sessionOnly, oidcHandler.Link)
+				protectedUsers.DELETE("/me/identities/:id", sessionOnly, oidcHandler.Unlink)
+				protectedUsers.POST("/:id/follow", usersScope, followHandler.Follow)
+				protectedUsers.DELETE("/:id/follow", usersScope, followHandler.Unfollow)
+				protectedUsers.POST("/:id/block", usersScope, blockHandler.Block)
+				protectedUsers.DELETE("/:id/block", usersScope, blockHandler.Unblock)
+				protectedUsers.POST("/:id/mute", usersScope, blockHandler.Mute)
+				protectedUsers.DELETE("/:id/mute", usersScope, blockHandler.Unmute)
 			}
 
 			// Post routes
 			protectedPosts := protected.Group("/posts")
 			{
-				protectedPosts.POST("/", postHandler.Create)
-				protectedPosts.PATCH("/:id", postHandler.Update)
-				protectedPosts.DELETE("/:id", postHandler.Delete)
-				protectedPosts.POST("/:id/like", likeHandler.Toggle)
-				protectedPosts.POST("/:id/comments", commentHandler.Create)
+				protectedPosts.POST("/", postsScope, idempotent, postHandler.Create)
+				protectedPosts.PATCH("/:id", postsScope, postHandler.Update)
+				protectedPosts.DELETE("/:id", postsScope, postHandler.Delete)
+				protectedPosts.POST("/:id/like", postsScope, idempotent, likeHandler.Toggle)
+				protectedPosts.POST("/:id/comments", postsScope, idempotent, commentHandler.Create)
+				protectedPosts.POST("/:id/report", postsScope, reportHandler.ReportPost)
+				protectedPosts.POST("/:id/poll/vote", postsScope, postHandler.Vote)
+				protectedPosts.POST("/:id/repost", postsScope, idempotent, postHandler.Repost)
+				protectedPosts.DELETE("/:id/repost", postsScope, postHandler.Unrepost)
+				protectedPosts.POST("/:id/bookmark", postsScope, bookmarkHandler.Add)
+				protectedPosts.DELETE("/:id/bookmark", postsScope, bookmarkHandler.Remove)
 			}
 
 			// Comment routes
 			protectedComments := protected.Group("/comments")
 			{
-				protectedComments.DELETE("/:id", commentHandler.Delete)
+				protectedComments.DELETE("/:id", postsScope, commentHandler.Delete)
+				protectedComments.POST("/:id/report", postsScope, reportHandler.ReportComment)
+			}
+
+			// Direct message routes, visible to participants only
+			conversations := protected.Group("/conversations")
+			{
+				conversations.GET("/", messagesScope, messageHandler.List)
+				conversations.POST("/", messagesScope, messageHandler.Start)
+				conversations.GET("/:id", messagesScope, messageHandler.Get)
+				conversations.DELETE("/:id", messagesScope, messageHandler.Leave)
+				conversations.GET("/:id/messages", messagesScope, messageHandler.Messages)
+				conversations.POST("/:id/messages", messagesScope, idempotent, messageHandler.Send)
+				conversations.POST("/:id/read", messagesScope, messageHandler.MarkRead)
+			}
+
+			// Community membership routes
+			protectedCommunities := protected.Group("/communities")
+			{
+				protectedCommunities.POST("/", communitiesScope, communityHandler.Create)
+				protectedCommunities.PATCH("/:slug", communitiesScope, communityHandler.Update)
+				protectedCommunities.POST("/:slug/membership", communitiesScope, communityHandler.Join)
+				protectedCommunities.DELETE("/:slug/membership", communitiesScope, communityHandler.Leave)
+				protectedCommunities.PATCH("/:slug/members/:user_id", communitiesScope, communityHandler.UpdateMember)
+				protectedCommunities.DELETE("/:slug/members/:user_id", communitiesScope, communityHandler.RemoveMember)
+				protectedCommunities.GET("/:slug/requests", communitiesScope, communityHandler.Requests)
+				protectedCommunities.POST("/:slug/requests/:user_id", communitiesScope, communityHandler.ApproveRequest)
+				protectedCommunities.DELETE("/:slug/requests/:user_id", communitiesScope, communityHandler.RejectRequest)
+			}
+
+			// Moderation routes
+			moderation := protected.Group("/moderation")
+			moderation.Use(sessionOnly, moderationHandler.RequireModerator)
+			{
+				moderation.GET("/reports", moderationHandler.Queue)
+				moderation.GET("/reports/:type/:id", moderationHandler.TargetReports)
+				moderation.POST("/reports/:type/:id/actions", moderationHandler.Act)
+				moderation.GET("/actions", moderationHandler.Actions)
+			}
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(sessionOnly, auditHandler.RequireAdmin)
+			{
+				admin.GET("/security-events", auditHandler.Query)
 			}
 		}
 	}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// CreateComment adds a comment by v to a post v can see, honouring blocks
+// and the author's who_can_comment setting. The comment is returned with
+// its author loaded.
+func (s *Service) CreateComment(v Viewer, postID uint, body string) (models.Comment, error) {
+	if v.ID == 0 {
+		return models.Comment{}, unauthenticated()
+	}
+	if strings.TrimSpace(body) == "" {
+		return models.Comment{}, invalid("body is required")
+	}
+
+	post, err := FindVisiblePost(s.db, v, postID)
+	if err != nil {
+		return models.Comment{}, notFound("post not found")
+	}
+
+	if IsBlocked(s.db, v.ID, post.UserID) {
+		return models.Comment{}, forbidden("you cannot interact with this user")
+	}
+
+	if post.UserID != v.ID {
+		var author models.User
+		s.db.Select("id, privacy_who_can_comment").First(&author, post.UserID)
+		if author.Privacy.WhoCanComment == models.CommentsNobody {
+			return models.Comment{}, forbidden("the author has turned off comments")
+		}
+		if author.Privacy.WhoCanComment == models.CommentsFollowers && !IsFollowing(s.db, v.ID, post.UserID) {
+			return models.Comment{}, forbidden("only followers can comment on this post")
+		}
+	}
+
+	comment := models.Comment{
+		Body:     body,
+		BodyHTML: utils.RenderMarkdown(body),
+		UserID:   v.ID,
+		PostID:   post.ID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return AdjustCounter(tx, post.ID, "comments_count", 1)
+	})
+	if err != nil {
+		return models.Comment{}, internal("failed to create comment", err)
+	}
+
+	s.db.Preload("User").First(&comment, comment.ID)
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Its author and the author of the post it
+// is on may delete it.
+func (s *Service) DeleteComment(v Viewer, id uint) error {
+	if v.ID == 0 {
+		return unauthenticated()
+	}
+
+	var comment models.Comment
+	if err := s.db.First(&comment, id).Error; err != nil {
+		return notFound("comment not found")
+	}
+
+	var post models.Post
+	s.db.Select("id, user_id").First(&post, comment.PostID)
+
+	if comment.UserID != v.ID && post.UserID != v.ID {
+		return forbidden("not authorized to delete this comment")
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return RemoveComment(tx, comment)
+	}); err != nil {
+		return internal("failed to delete comment", err)
+	}
+	return nil
+}
+
+// Comments starts a query over the comments on a post that v may read,
+// leaving out authors v has blocked or muted. The caller must already have
+// checked that v can see the post.
+func (s *Service) Comments(v Viewer, postID uint) *gorm.DB {
+	return s.db.Model(&models.Comment{}).
+		Where("comments.post_id = ?", postID).
+		Scopes(VisibleComments(v), ExcludeBlocked(v, "comments.user_id"), ExcludeMuted(v, "comments.user_id"))
+}
+
+// RemoveComment removes a comment and decrements its post's counter. Run it
+// inside a transaction.
+func RemoveComment(tx *gorm.DB, comment models.Comment) error {
+	result := tx.Delete(&models.Comment{}, comment.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	return AdjustCounter(tx, comment.PostID, "comments_count", -result.RowsAffected)
+}
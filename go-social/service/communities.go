@@ -0,0 +1,112 @@
+package service
+
+import (
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// FindCommunity loads a community by slug.
+func FindCommunity(db *gorm.DB, slug string) (models.Community, error) {
+	var community models.Community
+	err := db.Where("slug = ?", slug).First(&community).Error
+	return community, err
+}
+
+// CommunityRole returns userID's role in a community, or "" for
+// non-members.
+func CommunityRole(db *gorm.DB, communityID, userID uint) string {
+	if userID == 0 {
+		return ""
+	}
+
+	var role string
+	db.Model(&models.CommunityMember{}).Select("role").
+		Where("community_id = ? AND user_id = ?", communityID, userID).
+		Scan(&role)
+	return role
+}
+
+// CanModerateCommunity reports whether a role may manage members and posts.
+func CanModerateCommunity(role string) bool {
+	return role == models.CommunityRoleOwner || role == models.CommunityRoleModerator
+}
+
+// CanReadCommunity reports whether v may browse a community's posts and
+// members.
+func CanReadCommunity(db *gorm.DB, v Viewer, community models.Community) bool {
+	return community.Membership == models.MembershipPublic || v.IsAdmin() ||
+		CommunityRole(db, community.ID, v.ID) != ""
+}
+
+// InCommunity narrows a listing to the published, listed posts of one
+// community.
+func InCommunity(v Viewer, communityID uint) []func(*gorm.DB) *gorm.DB {
+	return []func(*gorm.DB) *gorm.DB{PublishedPosts, ListedPosts(v), func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.community_id = ?", communityID)
+	}}
+}
+
+// checkCanPost checks that v may post into a community: anyone may post in
+// a public one, only members in a private one.
+func (s *Service) checkCanPost(v Viewer, communityID uint) error {
+	var community models.Community
+	if err := s.db.First(&community, communityID).Error; err != nil {
+		return notFound("community not found")
+	}
+	if community.Membership == models.MembershipPrivate && CommunityRole(s.db, community.ID, v.ID) == "" {
+		return forbidden("only members can post in this community")
+	}
+	return nil
+}
+
+// LeaveCommunities takes userID out of every community and drops their
+// join requests. Communities they own pass to the longest-standing
+// moderator, or else member. Run it inside a transaction.
+func LeaveCommunities(tx *gorm.DB, userID uint) error {
+	var owned []uint
+	if err := tx.Model(&models.CommunityMember{}).
+		Where("user_id = ? AND role = ?", userID, models.CommunityRoleOwner).
+		Pluck("community_id", &owned).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", userID).Delete(&models.CommunityMember{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", userID).Delete(&models.JoinRequest{}).Error; err != nil {
+		return err
+	}
+
+	for _, communityID := range owned {
+		var heir models.CommunityMember
+		err := tx.Where("community_id = ?", communityID).
+			Order("role = 'moderator' DESC, id").
+			First(&heir).Error
+		if err == nil {
+			if err := tx.Model(&heir).Update("role", models.CommunityRoleOwner).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		// Nobody is left to take over. A community that still holds posts
+		// stays, ownerless, for admins to manage; an empty one goes.
+		var posts int64
+		if err := tx.Model(&models.Post{}).Where("community_id = ?", communityID).Count(&posts).Error; err != nil {
+			return err
+		}
+		if posts > 0 {
+			continue
+		}
+		if err := tx.Where("community_id = ?", communityID).Delete(&models.JoinRequest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Community{}, communityID).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import "fmt"
+
+// Code classifies a service error independently of the transport.
+type Code int
+
+const (
+	CodeInvalid Code = iota + 1
+	CodeUnauthenticated
+	CodeForbidden
+	CodeNotFound
+	CodeConflict
+	CodePreconditionFailed
+	CodeInternal
+)
+
+// Error is returned for every expected failure. Message is safe to show to
+// the caller; Err, if set, is the underlying cause.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func invalid(msg string) error   { return &Error{Code: CodeInvalid, Message: msg} }
+func forbidden(msg string) error { return &Error{Code: CodeForbidden, Message: msg} }
+func notFound(msg string) error  { return &Error{Code: CodeNotFound, Message: msg} }
+func unauthenticated() error {
+	return &Error{Code: CodeUnauthenticated, Message: "authentication required"}
+}
+func conflict(msg string) error { return &Error{Code: CodeConflict, Message: msg} }
+func internal(msg string, err error) error {
+	return &Error{Code: CodeInternal, Message: msg, Err: err}
+}
+
+// ErrPostChanged is returned when an If-Match precondition fails.
+var ErrPostChanged = &Error{Code: CodePreconditionFailed, Message: "post has changed since it was fetched"}
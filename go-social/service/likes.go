@@ -0,0 +1,84 @@
+package service
+
+import (
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ToggleLike likes a post v can see, or unlikes it if v already does, and
+// reports whether the post is now liked.
+func (s *Service) ToggleLike(v Viewer, postID uint) (bool, error) {
+	post, err := s.likeablePost(v, postID)
+	if err != nil {
+		return false, err
+	}
+
+	// Both branches key off the rows actually changed, so the counter stays
+	// exact under concurrent toggles
+	liked := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		removed, err := unlike(tx, v.ID, post.ID)
+		if err != nil || removed {
+			return err
+		}
+		liked = true
+		return like(tx, v.ID, post.ID)
+	})
+	if err != nil {
+		return false, internal("failed to toggle like", err)
+	}
+	return liked, nil
+}
+
+// SetLike makes v like or not like a post. Repeating a call changes
+// nothing, which suits clients that retry.
+func (s *Service) SetLike(v Viewer, postID uint, liked bool) error {
+	post, err := s.likeablePost(v, postID)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if liked {
+			return like(tx, v.ID, post.ID)
+		}
+		_, err := unlike(tx, v.ID, post.ID)
+		return err
+	})
+	if err != nil {
+		return internal("failed to update like", err)
+	}
+	return nil
+}
+
+func (s *Service) likeablePost(v Viewer, postID uint) (models.Post, error) {
+	if v.ID == 0 {
+		return models.Post{}, unauthenticated()
+	}
+
+	post, err := FindVisiblePost(s.db, v, postID)
+	if err != nil {
+		return post, notFound("post not found")
+	}
+	if IsBlocked(s.db, v.ID, post.UserID) {
+		return post, forbidden("you cannot interact with this user")
+	}
+	return post, nil
+}
+
+func like(tx *gorm.DB, userID, postID uint) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Like{UserID: userID, PostID: postID})
+	if result.Error != nil {
+		return result.Error
+	}
+	return AdjustCounter(tx, postID, "likes_count", result.RowsAffected)
+}
+
+func unlike(tx *gorm.DB, userID, postID uint) (bool, error) {
+	result := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.Like{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, AdjustCounter(tx, postID, "likes_count", -result.RowsAffected)
+}
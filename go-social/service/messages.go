@@ -0,0 +1,399 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// ConversationSummary is an inbox entry: the conversation with its
+// participants, the newest message v may read and v's unread count.
+type ConversationSummary struct {
+	models.Conversation
+	Participants []models.Participant
+	LastMessage  *models.Message
+	Unread       int64
+}
+
+func ConversationCursor(conversation models.Conversation) string {
+	return encodeCursor("conversation:%d:%d", conversation.ActivityAt.UnixMicro(), conversation.ID)
+}
+
+func MessageCursor(message models.Message) string {
+	return encodeCursor("message:%d", message.ID)
+}
+
+// StartConversation opens a conversation between v and the users named by
+// refs, IDs or @handles. Two people share a single direct conversation, so
+// starting one again returns the existing thread. A non-empty body is sent
+// as the first message. Nobody on either side of a block with v can be
+// added.
+func (s *Service) StartConversation(v Viewer, refs []string, body string) (ConversationSummary, error) {
+	if v.ID == 0 {
+		return ConversationSummary{}, unauthenticated()
+	}
+
+	var others []uint
+	seen := map[uint]bool{v.ID: true}
+	for _, ref := range refs {
+		user, err := FindUser(s.db, strings.TrimSpace(ref))
+		if err != nil {
+			return ConversationSummary{}, notFound(fmt.Sprintf("user %s not found", ref))
+		}
+		if seen[user.ID] {
+			continue
+		}
+		seen[user.ID] = true
+		if IsBlocked(s.db, v.ID, user.ID) {
+			return ConversationSummary{}, forbidden("you cannot message this user")
+		}
+		others = append(others, user.ID)
+	}
+	if len(others) == 0 {
+		return ConversationSummary{}, invalid("at least one other participant is required")
+	}
+	if len(others)+1 > models.MaxParticipants {
+		return ConversationSummary{}, invalid(fmt.Sprintf("a conversation can have at most %d participants", models.MaxParticipants))
+	}
+
+	var conversation models.Conversation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(others) == 1 {
+			err := tx.Where("NOT is_group").
+				Where("id IN (SELECT conversation_id FROM participants WHERE user_id = ?)", v.ID).
+				Where("id IN (SELECT conversation_id FROM participants WHERE user_id = ?)", others[0]).
+				First(&conversation).Error
+			if err == nil {
+				return nil
+			}
+			if err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+
+		conversation = models.Conversation{
+			IsGroup:     len(others) > 1,
+			CreatedByID: v.ID,
+			ActivityAt:  time.Now(),
+		}
+		if err := tx.Create(&conversation).Error; err != nil {
+			return err
+		}
+
+		participants := []models.Participant{{ConversationID: conversation.ID, UserID: v.ID}}
+		for _, id := range others {
+			participants = append(participants, models.Participant{ConversationID: conversation.ID, UserID: id})
+		}
+		return tx.Create(&participants).Error
+	})
+	if err != nil {
+		return ConversationSummary{}, internal("failed to start conversation", err)
+	}
+
+	if strings.TrimSpace(body) != "" {
+		if _, err := s.SendMessage(v, conversation.ID, body); err != nil {
+			return ConversationSummary{}, err
+		}
+	}
+	return s.GetConversation(v, conversation.ID)
+}
+
+// GetConversation returns one of v's conversations.
+func (s *Service) GetConversation(v Viewer, id uint) (ConversationSummary, error) {
+	conversation, err := s.conversationOf(v, id)
+	if err != nil {
+		return ConversationSummary{}, err
+	}
+
+	summaries := []ConversationSummary{{Conversation: conversation}}
+	if err := s.summarize(v, summaries); err != nil {
+		return ConversationSummary{}, err
+	}
+	return summaries[0], nil
+}
+
+// ConversationPage returns up to limit of v's conversations after the
+// cursor, most recently active first, and whether more follow.
+func (s *Service) ConversationPage(v Viewer, limit int, after string) ([]ConversationSummary, bool, error) {
+	if v.ID == 0 {
+		return nil, false, unauthenticated()
+	}
+	if err := checkLimit(limit); err != nil {
+		return nil, false, err
+	}
+
+	query := s.db.Where("conversations.id IN (SELECT conversation_id FROM participants WHERE user_id = ?)", v.ID)
+	if after != "" {
+		var micros int64
+		var id uint
+		if err := decodeCursor(after, "conversation:%d:%d", &micros, &id); err != nil {
+			return nil, false, err
+		}
+		query = query.Where("(conversations.activity_at, conversations.id) < (?, ?)", time.UnixMicro(micros), id)
+	}
+
+	var conversations []models.Conversation
+	if err := query.Order("conversations.activity_at DESC, conversations.id DESC").Limit(limit + 1).Find(&conversations).Error; err != nil {
+		return nil, false, internal("failed to fetch conversations", err)
+	}
+
+	more := len(conversations) > limit
+	if more {
+		conversations = conversations[:limit]
+	}
+
+	summaries := make([]ConversationSummary, len(conversations))
+	for i, conversation := range conversations {
+		summaries[i].Conversation = conversation
+	}
+	if err := s.summarize(v, summaries); err != nil {
+		return nil, false, err
+	}
+	return summaries, more, nil
+}
+
+// summarize loads the participants, last message and unread count of each
+// summary with one query apiece.
+func (s *Service) summarize(v Viewer, summaries []ConversationSummary) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(summaries))
+	for i, summary := range summaries {
+		ids[i] = summary.ID
+	}
+
+	var participants []models.Participant
+	if err := s.db.Preload("User").Where("conversation_id IN ?", ids).Order("id").Find(&participants).Error; err != nil {
+		return internal("failed to fetch participants", err)
+	}
+
+	var last []models.Message
+	if err := s.db.Select("DISTINCT ON (messages.conversation_id) messages.*").
+		Scopes(s.readableMessages(v)).
+		Where("messages.conversation_id IN ?", ids).
+		Order("messages.conversation_id, messages.id DESC").
+		Preload("Sender").
+		Find(&last).Error; err != nil {
+		return internal("failed to fetch messages", err)
+	}
+
+	var unread []struct {
+		ConversationID uint
+		Count          int64
+	}
+	if err := s.db.Model(&models.Message{}).Scopes(s.unreadMessages(v)).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Where("messages.conversation_id IN ?", ids).
+		Group("messages.conversation_id").
+		Scan(&unread).Error; err != nil {
+		return internal("failed to count unread messages", err)
+	}
+
+	index := make(map[uint]int, len(summaries))
+	for i, summary := range summaries {
+		index[summary.ID] = i
+	}
+	for _, participant := range participants {
+		i := index[participant.ConversationID]
+		summaries[i].Participants = append(summaries[i].Participants, participant)
+	}
+	for i := range last {
+		summaries[index[last[i].ConversationID]].LastMessage = &last[i]
+	}
+	for _, row := range unread {
+		summaries[index[row.ConversationID]].Unread = row.Count
+	}
+	return nil
+}
+
+// UnreadMessages counts the messages waiting for v across all of v's
+// conversations.
+func (s *Service) UnreadMessages(v Viewer) (int64, error) {
+	var count int64
+	if err := s.db.Model(&models.Message{}).Scopes(s.unreadMessages(v)).Count(&count).Error; err != nil {
+		return 0, internal("failed to count unread messages", err)
+	}
+	return count, nil
+}
+
+// MessagePage returns up to limit messages of a conversation before the
+// cursor, newest first, and whether older ones follow.
+func (s *Service) MessagePage(v Viewer, conversationID uint, limit int, before string) ([]models.Message, bool, error) {
+	if err := checkLimit(limit); err != nil {
+		return nil, false, err
+	}
+	if _, err := s.conversationOf(v, conversationID); err != nil {
+		return nil, false, err
+	}
+
+	query := s.db.Scopes(s.readableMessages(v)).Where("messages.conversation_id = ?", conversationID)
+	if before != "" {
+		var id uint
+		if err := decodeCursor(before, "message:%d", &id); err != nil {
+			return nil, false, err
+		}
+		query = query.Where("messages.id < ?", id)
+	}
+
+	var messages []models.Message
+	if err := query.Preload("Sender").Order("messages.id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, false, internal("failed to fetch messages", err)
+	}
+	if len(messages) > limit {
+		return messages[:limit], true, nil
+	}
+	return messages, false, nil
+}
+
+// SendMessage posts a message from v. In a direct conversation a block on
+// either side stops delivery; in a group, blocked members simply do not see
+// each other's messages. The sender's own message counts as read.
+func (s *Service) SendMessage(v Viewer, conversationID uint, body string) (models.Message, error) {
+	if strings.TrimSpace(body) == "" {
+		return models.Message{}, invalid("body is required")
+	}
+
+	conversation, err := s.conversationOf(v, conversationID)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	if !conversation.IsGroup {
+		var count int64
+		s.db.Model(&models.Participant{}).
+			Where("conversation_id = ? AND user_id <> ?", conversation.ID, v.ID).
+			Where("user_id IN (SELECT blocked_id FROM blocks WHERE blocker_id = ? UNION SELECT blocker_id FROM blocks WHERE blocked_id = ?)", v.ID, v.ID).
+			Count(&count)
+		if count > 0 {
+			return models.Message{}, forbidden("you cannot message this user")
+		}
+	}
+
+	message := models.Message{ConversationID: conversation.ID, SenderID: v.ID, Body: body}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Conversation{}).Where("id = ?", conversation.ID).
+			Update("activity_at", message.CreatedAt).Error; err != nil {
+			return err
+		}
+		return markRead(tx, conversation.ID, v.ID, message.ID)
+	})
+	if err != nil {
+		return models.Message{}, internal("failed to send message", err)
+	}
+
+	s.db.Preload("Sender").First(&message, message.ID)
+	return message, nil
+}
+
+// MarkRead records that v has read a conversation up to messageID, or up to
+// the newest message when messageID is 0. The read position never moves
+// backwards.
+func (s *Service) MarkRead(v Viewer, conversationID, messageID uint) (models.Participant, error) {
+	if _, err := s.conversationOf(v, conversationID); err != nil {
+		return models.Participant{}, err
+	}
+
+	var message models.Message
+	query := s.db.Where("conversation_id = ?", conversationID)
+	if messageID != 0 {
+		query = query.Where("id = ?", messageID)
+	}
+	if err := query.Order("id DESC").First(&message).Error; err != nil && messageID != 0 {
+		return models.Participant{}, notFound("message not found")
+	}
+
+	if err := markRead(s.db, conversationID, v.ID, message.ID); err != nil {
+		return models.Participant{}, internal("failed to mark conversation read", err)
+	}
+
+	var participant models.Participant
+	s.db.Where("conversation_id = ? AND user_id = ?", conversationID, v.ID).First(&participant)
+	return participant, nil
+}
+
+// LeaveConversation takes v out of a conversation, deleting the messages v
+// sent to it.
+func (s *Service) LeaveConversation(v Viewer, conversationID uint) error {
+	if _, err := s.conversationOf(v, conversationID); err != nil {
+		return err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return RemoveParticipant(tx, v.ID, []uint{conversationID})
+	})
+	if err != nil {
+		return internal("failed to leave conversation", err)
+	}
+	return nil
+}
+
+// RemoveParticipant takes userID out of the given conversations, deleting
+// the messages they sent there. A conversation nobody is left in is deleted
+// with it.
+func RemoveParticipant(tx *gorm.DB, userID uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Where("sender_id = ? AND conversation_id IN ?", userID, ids).Delete(&models.Message{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ? AND conversation_id IN ?", userID, ids).Delete(&models.Participant{}).Error; err != nil {
+		return err
+	}
+
+	empty := tx.Model(&models.Conversation{}).Select("id").
+		Where("id IN ? AND id NOT IN (SELECT conversation_id FROM participants)", ids)
+	if err := tx.Where("conversation_id IN (?)", empty).Delete(&models.Message{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ? AND id NOT IN (SELECT conversation_id FROM participants)", ids).Delete(&models.Conversation{}).Error
+}
+
+// conversationOf loads a conversation v takes part in. Everyone else gets
+// notFound, so conversation IDs reveal nothing.
+func (s *Service) conversationOf(v Viewer, id uint) (models.Conversation, error) {
+	if v.ID == 0 {
+		return models.Conversation{}, unauthenticated()
+	}
+
+	var conversation models.Conversation
+	if err := s.db.Where("id = ? AND id IN (SELECT conversation_id FROM participants WHERE user_id = ?)", id, v.ID).
+		First(&conversation).Error; err != nil {
+		return models.Conversation{}, notFound("conversation not found")
+	}
+	return conversation, nil
+}
+
+// readableMessages limits a query to messages in v's conversations, leaving
+// out those from users on either side of a block with v.
+func (s *Service) readableMessages(v Viewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("messages.conversation_id IN (SELECT conversation_id FROM participants WHERE user_id = ?)", v.ID).
+			Scopes(ExcludeBlocked(v, "messages.sender_id"))
+	}
+}
+
+// unreadMessages limits a query to readable messages from others that are
+// newer than v's read position.
+func (s *Service) unreadMessages(v Viewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN participants ON participants.conversation_id = messages.conversation_id AND participants.user_id = ?", v.ID).
+			Where("messages.id > participants.last_read_message_id AND messages.sender_id <> ?", v.ID).
+			Scopes(ExcludeBlocked(v, "messages.sender_id"))
+	}
+}
+
+func markRead(tx *gorm.DB, conversationID, userID, messageID uint) error {
+	return tx.Model(&models.Participant{}).
+		Where("conversation_id = ? AND user_id = ? AND last_read_message_id < ?", conversationID, userID, messageID).
+		Updates(map[string]interface{}{"last_read_message_id": messageID, "last_read_at": time.Now()}).Error
+}
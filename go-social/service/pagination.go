@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// MaxPageSize caps cursor-paged listings.
+const MaxPageSize = 100
+
+// Cursors are opaque to clients. Post cursors hold the sort key of the
+// timeline order, published_at then ID; comment and like cursors hold the
+// row ID.
+
+func encodeCursor(format string, args ...interface{}) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(format, args...)))
+}
+
+func decodeCursor(cursor, format string, args ...interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		_, err = fmt.Sscanf(string(raw), format, args...)
+	}
+	if err != nil {
+		return invalid("invalid cursor")
+	}
+	return nil
+}
+
+// cursorFields splits a cursor made of n colon-separated fields.
+func cursorFields(cursor string, n int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	fields := strings.Split(string(raw), ":")
+	if err != nil || len(fields) != n {
+		return nil, invalid("invalid cursor")
+	}
+	return fields, nil
+}
+
+func PostCursor(post models.Post) string {
+	at := post.CreatedAt
+	if post.PublishedAt != nil {
+		at = *post.PublishedAt
+	}
+	return encodeCursor("post:%d:%d", at.UnixMicro(), post.ID)
+}
+
+func CommentCursor(comment models.Comment) string {
+	return encodeCursor("comment:%d", comment.ID)
+}
+
+func LikeCursor(like models.Like) string {
+	return encodeCursor("like:%d", like.ID)
+}
+
+func checkLimit(limit int) error {
+	if limit < 1 || limit > MaxPageSize {
+		return invalid(fmt.Sprintf("page size must be between 1 and %d", MaxPageSize))
+	}
+	return nil
+}
+
+// PostPage returns up to limit posts v can see after the cursor, newest
+// first, narrowed by scopes that must keep to published posts, and whether
+// more follow.
+func (s *Service) PostPage(v Viewer, limit int, after string, scopes ...func(*gorm.DB) *gorm.DB) ([]models.Post, bool, error) {
+	if err := checkLimit(limit); err != nil {
+		return nil, false, err
+	}
+
+	query := s.Posts(v, scopes...)
+	if after != "" {
+		var micros int64
+		var id uint
+		if err := decodeCursor(after, "post:%d:%d", &micros, &id); err != nil {
+			return nil, false, err
+		}
+		query = query.Where("(posts.published_at, posts.id) < (?, ?)", time.UnixMicro(micros), id)
+	}
+
+	var posts []models.Post
+	if err := query.Order("posts.published_at DESC, posts.id DESC").Limit(limit + 1).Find(&posts).Error; err != nil {
+		return nil, false, internal("failed to fetch posts", err)
+	}
+	if len(posts) > limit {
+		return posts[:limit], true, nil
+	}
+	return posts, false, nil
+}
+
+// CommentPage returns up to limit comments v may read on a post after the
+// cursor, oldest first, and whether more follow. The caller must already
+// have checked that v can see the post.
+func (s *Service) CommentPage(v Viewer, postID uint, limit int, after string) ([]models.Comment, bool, error) {
+	if err := checkLimit(limit); err != nil {
+		return nil, false, err
+	}
+
+	query := s.Comments(v, postID)
+	if after != "" {
+		var id uint
+		if err := decodeCursor(after, "comment:%d", &id); err != nil {
+			return nil, false, err
+		}
+		query = query.Where("comments.id > ?", id)
+	}
+
+	var comments []models.Comment
+	if err := query.Order("comments.id ASC").Limit(limit + 1).Find(&comments).Error; err != nil {
+		return nil, false, internal("failed to fetch comments", err)
+	}
+	if len(comments) > limit {
+		return comments[:limit], true, nil
+	}
+	return comments, false, nil
+}
+
+// LikePage returns up to limit of user's likes after the cursor, most
+// recent first, and whether more follow. Only likes on posts v could see in
+// listings are included; the caller must check v.CanSeeLikes first.
+func (s *Service) LikePage(v Viewer, userID uint, limit int, after string) ([]models.Like, bool, error) {
+	if err := checkLimit(limit); err != nil {
+		return nil, false, err
+	}
+
+	visible := s.Posts(v, LikedBy(v, userID)...).Select("posts.id")
+	query := s.db.Model(&models.Like{}).Where("likes.user_id = ? AND likes.post_id IN (?)", userID, visible)
+	if after != "" {
+		var id uint
+		if err := decodeCursor(after, "like:%d", &id); err != nil {
+			return nil, false, err
+		}
+		query = query.Where("likes.id < ?", id)
+	}
+
+	var likes []models.Like
+	if err := query.Order("likes.id DESC").Limit(limit + 1).Find(&likes).Error; err != nil {
+		return nil, false, internal("failed to fetch likes", err)
+	}
+	if len(likes) > limit {
+		return likes[:limit], true, nil
+	}
+	return likes, false, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxPollDuration is how far ahead a poll may close.
+const MaxPollDuration = 30 * 24 * time.Hour
+
+// PollInput describes a poll attached to a new post.
+type PollInput struct {
+	Options        []string
+	ClosesAt       time.Time
+	MultipleChoice bool
+}
+
+func validatePoll(in *PollInput, now time.Time) error {
+	if len(in.Options) < models.MinPollOptions || len(in.Options) > models.MaxPollOptions {
+		return invalid(fmt.Sprintf("a poll needs between %d and %d options", models.MinPollOptions, models.MaxPollOptions))
+	}
+
+	seen := make(map[string]bool, len(in.Options))
+	for i, option := range in.Options {
+		option = strings.TrimSpace(option)
+		if n := utf8.RuneCountInString(option); n < 1 || n > 100 {
+			return invalid("poll options must be between 1 and 100 characters")
+		}
+		if seen[strings.ToLower(option)] {
+			return invalid("poll options must be distinct")
+		}
+		seen[strings.ToLower(option)] = true
+		in.Options[i] = option
+	}
+
+	if !in.ClosesAt.After(now) {
+		return invalid("closes_at must be in the future")
+	}
+	if in.ClosesAt.After(now.Add(MaxPollDuration)) {
+		return invalid("polls can stay open for at most 30 days")
+	}
+	return nil
+}
+
+func createPoll(tx *gorm.DB, postID uint, in *PollInput) error {
+	poll := models.Poll{PostID: postID, MultipleChoice: in.MultipleChoice, ClosesAt: in.ClosesAt}
+	for i, text := range in.Options {
+		poll.Options = append(poll.Options, models.PollOption{Position: i, Text: text})
+	}
+	return tx.Create(&poll).Error
+}
+
+// AttachPolls sets Poll on each post that has one, with its options and the
+// choices v made.
+func AttachPolls(db *gorm.DB, v Viewer, posts []models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	var polls []models.Poll
+	db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("post_id IN ?", postIDs).Find(&polls)
+	if len(polls) == 0 {
+		return
+	}
+
+	byPoll := make(map[uint]*models.Poll, len(polls))
+	byPost := make(map[uint]*models.Poll, len(polls))
+	pollIDs := make([]uint, 0, len(polls))
+	for i := range polls {
+		byPoll[polls[i].ID] = &polls[i]
+		byPost[polls[i].PostID] = &polls[i]
+		pollIDs = append(pollIDs, polls[i].ID)
+	}
+
+	if v.ID != 0 {
+		var choices []struct {
+			PollID   uint
+			OptionID uint
+		}
+		db.Model(&models.PollVote{}).
+			Select("poll_ballots.poll_id, poll_votes.option_id").
+			Joins("JOIN poll_ballots ON poll_ballots.id = poll_votes.ballot_id").
+			Where("poll_ballots.user_id = ? AND poll_ballots.poll_id IN ?", v.ID, pollIDs).
+			Order("poll_votes.option_id").
+			Scan(&choices)
+		for _, choice := range choices {
+			poll := byPoll[choice.PollID]
+			poll.MyChoices = append(poll.MyChoices, choice.OptionID)
+		}
+	}
+
+	for i := range posts {
+		posts[i].Poll = byPost[posts[i].ID]
+	}
+}
+
+// Vote casts v's ballot in the poll on a post v can see. Single-choice polls
+// take exactly one option. A ballot cannot be changed once cast.
+func (s *Service) Vote(v Viewer, postID uint, optionIDs []uint) (models.Post, error) {
+	if v.ID == 0 {
+		return models.Post{}, unauthenticated()
+	}
+
+	post, err := FindVisiblePost(s.db, v, postID)
+	if err != nil {
+		return models.Post{}, notFound("post not found")
+	}
+	if IsBlocked(s.db, v.ID, post.UserID) {
+		return models.Post{}, forbidden("you cannot interact with this user")
+	}
+
+	var poll models.Poll
+	if err := s.db.Preload("Options").Where("post_id = ?", post.ID).First(&poll).Error; err != nil {
+		return models.Post{}, notFound("this post has no poll")
+	}
+	if poll.Closed(time.Now()) {
+		return models.Post{}, forbidden("this poll is closed")
+	}
+
+	if len(optionIDs) == 0 {
+		return models.Post{}, invalid("choose at least one option")
+	}
+	if !poll.MultipleChoice && len(optionIDs) > 1 {
+		return models.Post{}, invalid("this poll takes exactly one option")
+	}
+
+	valid := make(map[uint]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+	chosen := make(map[uint]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if !valid[id] {
+			return models.Post{}, invalid("option is not part of this poll")
+		}
+		if chosen[id] {
+			return models.Post{}, invalid("each option can be chosen once")
+		}
+		chosen[id] = true
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The unique (poll_id, user_id) index settles concurrent ballots
+		// from the same user; counters only move for the one that lands
+		ballot := models.PollBallot{PollID: poll.ID, UserID: v.ID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ballot)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return conflict("you have already voted in this poll")
+		}
+
+		votes := make([]models.PollVote, 0, len(optionIDs))
+		for _, id := range optionIDs {
+			votes = append(votes, models.PollVote{BallotID: ballot.ID, OptionID: id})
+		}
+		if err := tx.Create(&votes).Error; err != nil {
+			return err
+		}
+
+		// Re-check the deadline in the same statement that counts the
+		// voter, so a ballot racing the close is rejected
+		result = tx.Model(&models.Poll{}).Where("id = ? AND closes_at > NOW()", poll.ID).
+			UpdateColumn("voters_count", gorm.Expr("voters_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return forbidden("this poll is closed")
+		}
+
+		if err := tx.Model(&models.PollOption{}).Where("id IN ?", optionIDs).
+			UpdateColumn("votes_count", gorm.Expr("votes_count + 1")).Error; err != nil {
+			return err
+		}
+		// New results change the rendered post
+		return tx.Model(&models.Post{}).Where("id = ?", post.ID).
+			UpdateColumn("activity_at", gorm.Expr("NOW()")).Error
+	})
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			return models.Post{}, err
+		}
+		return models.Post{}, internal("failed to record vote", err)
+	}
+
+	return s.GetPost(v, post.ID)
+}
+
+// RemovePolls deletes the polls on postIDs with their ballots. Run it inside
+// a transaction.
+func RemovePolls(tx *gorm.DB, postIDs []uint) error {
+	polls := tx.Model(&models.Poll{}).Select("id").Where("post_id IN ?", postIDs)
+	ballots := tx.Model(&models.PollBallot{}).Select("id").Where("poll_id IN (?)", polls)
+	if err := tx.Where("ballot_id IN (?)", ballots).Delete(&models.PollVote{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("poll_id IN (?)", polls).Delete(&models.PollBallot{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("poll_id IN (?)", polls).Delete(&models.PollOption{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("post_id IN ?", postIDs).Delete(&models.Poll{}).Error
+}
+
+// RemoveBallots withdraws userID's votes from every poll, taking them off
+// the counters. Run it inside a transaction.
+func RemoveBallots(tx *gorm.DB, userID uint) error {
+	ballots := tx.Model(&models.PollBallot{}).Select("id").Where("user_id = ?", userID)
+	if err := tx.Exec(`UPDATE poll_options SET votes_count = votes_count - 1
+		WHERE id IN (SELECT option_id FROM poll_votes WHERE ballot_id IN (?))`, ballots).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`UPDATE polls SET voters_count = voters_count - 1
+		WHERE id IN (SELECT poll_id FROM poll_ballots WHERE user_id = ?)`, userID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("ballot_id IN (?)", ballots).Delete(&models.PollVote{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.PollBallot{}).Error
+}
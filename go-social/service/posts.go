@@ -0,0 +1,379 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// PostInput describes a new post. Empty Status publishes it immediately and
+// empty Visibility makes it public. A non-nil CommunityID posts it into that
+// community, a non-nil Poll attaches a poll and a non-nil QuoteOfID quotes
+// another post.
+type PostInput struct {
+	Title       string
+	Body        string
+	Status      string
+	PublishAt   *time.Time
+	Visibility  string
+	CommunityID *uint
+	Poll        *PollInput
+	QuoteOfID   *uint
+}
+
+// PostUpdate describes changes to a post. Nil or empty fields are left as
+// they are.
+type PostUpdate struct {
+	Title      *string
+	Body       *string
+	Status     string
+	PublishAt  *time.Time
+	Visibility string
+}
+
+func validatePost(title, body, status, visibility string) error {
+	if n := utf8.RuneCountInString(title); n < 1 || n > 200 {
+		return invalid("title must be between 1 and 200 characters")
+	}
+	if utf8.RuneCountInString(body) > 10000 {
+		return invalid("body must be at most 10000 characters")
+	}
+	switch status {
+	case "", models.StatusDraft, models.StatusScheduled, models.StatusPublished:
+	default:
+		return invalid("status must be one of draft, scheduled, published")
+	}
+	switch visibility {
+	case "", models.VisibilityPublic, models.VisibilityFollowers, models.VisibilityUnlisted, models.VisibilityPrivate:
+	default:
+		return invalid("visibility must be one of public, followers, unlisted, private")
+	}
+	return nil
+}
+
+// applyStatus moves post to the requested status. An empty status
+// publishes new posts and leaves existing ones as they are.
+func applyStatus(post *models.Post, status string, publishAt *time.Time) error {
+	if status == "" {
+		status = post.Status
+		if post.ID == 0 {
+			status = models.StatusPublished
+		}
+	}
+
+	if post.Status == models.StatusPublished && status != models.StatusPublished {
+		return invalid("published posts cannot be moved back to drafts or scheduled")
+	}
+
+	now := time.Now()
+	switch status {
+	case models.StatusDraft:
+		post.PublishAt = nil
+	case models.StatusScheduled:
+		if publishAt == nil {
+			publishAt = post.PublishAt
+		}
+		if publishAt == nil || !publishAt.After(now) {
+			return invalid("scheduled posts need a publish_at in the future")
+		}
+		post.PublishAt = publishAt
+	case models.StatusPublished:
+		if post.PublishedAt == nil {
+			post.PublishedAt = &now
+		}
+		if post.Status != models.StatusPublished {
+			post.LiveAt = &now
+		}
+	}
+
+	post.Status = status
+	return nil
+}
+
+// CreatePost writes a new post by v and returns it with its author loaded.
+func (s *Service) CreatePost(v Viewer, in PostInput) (models.Post, error) {
+	if v.ID == 0 {
+		return models.Post{}, unauthenticated()
+	}
+	if err := validatePost(in.Title, in.Body, in.Status, in.Visibility); err != nil {
+		return models.Post{}, err
+	}
+
+	if in.Poll != nil {
+		if err := validatePoll(in.Poll, time.Now()); err != nil {
+			return models.Post{}, err
+		}
+	}
+	if in.CommunityID != nil {
+		if err := s.checkCanPost(v, *in.CommunityID); err != nil {
+			return models.Post{}, err
+		}
+	}
+	var quoted *uint
+	if in.QuoteOfID != nil {
+		original, err := s.repostable(v, *in.QuoteOfID)
+		if err != nil {
+			return models.Post{}, err
+		}
+		quoted = &original.ID
+	}
+
+	post := models.Post{
+		Title:       in.Title,
+		Body:        in.Body,
+		BodyHTML:    utils.RenderMarkdown(in.Body),
+		UserID:      v.ID,
+		Visibility:  in.Visibility,
+		CommunityID: in.CommunityID,
+		QuoteOfID:   quoted,
+	}
+	if post.Visibility == "" {
+		post.Visibility = models.VisibilityPublic
+	}
+	if err := applyStatus(&post, in.Status, in.PublishAt); err != nil {
+		return models.Post{}, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if in.Poll != nil {
+			if err := createPoll(tx, post.ID, in.Poll); err != nil {
+				return err
+			}
+		}
+		if quoted != nil {
+			if err := AdjustCounter(tx, *quoted, "repost_count", 1); err != nil {
+				return err
+			}
+		}
+		return QueueLinkPreview(tx, post.ID, post.BodyHTML)
+	})
+	if err != nil {
+		return models.Post{}, internal("failed to create post", err)
+	}
+
+	s.db.Preload("User").First(&post, post.ID)
+	posts := []models.Post{post}
+	AttachPolls(s.db, v, posts)
+	s.AttachOriginals(v, posts)
+	return posts[0], nil
+}
+
+// GetPost returns a post v may see, with its author loaded.
+func (s *Service) GetPost(v Viewer, id uint) (models.Post, error) {
+	post, err := FindVisiblePost(s.db, v, id)
+	if err != nil {
+		return post, notFound("post not found")
+	}
+
+	s.db.First(&post.User, post.UserID)
+	posts := []models.Post{post}
+	AttachLinkPreviews(s.db, posts)
+	AttachPolls(s.db, v, posts)
+	s.AttachOriginals(v, posts)
+	return posts[0], nil
+}
+
+// UpdatePost applies changes to one of v's posts. A non-empty ifMatch must
+// name the current version (see utils.IfMatchVersion) and the write only
+// happens if nobody else saves in the meantime; otherwise ErrPostChanged
+// is returned.
+func (s *Service) UpdatePost(v Viewer, id uint, in PostUpdate, ifMatch string) (models.Post, error) {
+	post, err := s.ownPost(v, id)
+	if err != nil {
+		return post, err
+	}
+
+	if ifMatch != "" && !utils.IfMatchVersion(ifMatch, post) {
+		return post, ErrPostChanged
+	}
+	if post.RepostOfID != nil {
+		return post, invalid("reposts cannot be edited")
+	}
+
+	title, body := post.Title, post.Body
+	if in.Title != nil {
+		title = *in.Title
+	}
+	if in.Body != nil {
+		body = *in.Body
+	}
+	if err := validatePost(title, body, in.Status, in.Visibility); err != nil {
+		return post, err
+	}
+
+	if err := applyStatus(&post, in.Status, in.PublishAt); err != nil {
+		return post, err
+	}
+	if in.Visibility != "" {
+		post.Visibility = in.Visibility
+	}
+
+	bodyHTML := utils.RenderMarkdown(body)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&post)
+		if ifMatch != "" {
+			query = query.Where("updated_at = ?", post.UpdatedAt)
+		}
+		result := query.Updates(map[string]interface{}{
+			"visibility":   post.Visibility,
+			"title":        title,
+			"body":         body,
+			"body_html":    bodyHTML,
+			"status":       post.Status,
+			"publish_at":   post.PublishAt,
+			"published_at": post.PublishedAt,
+			"live_at":      post.LiveAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPostChanged
+		}
+		return QueueLinkPreview(tx, post.ID, bodyHTML)
+	})
+	if err == ErrPostChanged {
+		return post, err
+	}
+	if err != nil {
+		return post, internal("failed to update post", err)
+	}
+
+	s.db.Preload("User").First(&post, post.ID)
+	posts := []models.Post{post}
+	AttachLinkPreviews(s.db, posts)
+	AttachPolls(s.db, v, posts)
+	s.AttachOriginals(v, posts)
+	return posts[0], nil
+}
+
+// DeletePost removes a post with everything attached to it. Besides the
+// author, the owner and moderators of the post's community may delete it.
+func (s *Service) DeletePost(v Viewer, id uint) error {
+	post, err := s.ownPost(v, id)
+	if IsForbidden(err) && post.CommunityID != nil && CanModerateCommunity(CommunityRole(s.db, *post.CommunityID, v.ID)) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return RemovePost(tx, post.ID)
+	}); err != nil {
+		return internal("failed to delete post", err)
+	}
+	return nil
+}
+
+// ownPost loads a post for modification by its author.
+func (s *Service) ownPost(v Viewer, id uint) (models.Post, error) {
+	var post models.Post
+	if v.ID == 0 {
+		return post, unauthenticated()
+	}
+	if err := s.db.First(&post, id).Error; err != nil {
+		return post, notFound("post not found")
+	}
+	if post.UserID != v.ID {
+		return post, forbidden("not the post owner")
+	}
+	return post, nil
+}
+
+// Posts starts a query over the posts v may see, leaving out posts by users
+// on either side of a block. Callers add their own narrowing scopes.
+func (s *Service) Posts(v Viewer, scopes ...func(*gorm.DB) *gorm.DB) *gorm.DB {
+	scopes = append([]func(*gorm.DB) *gorm.DB{VisiblePosts(v), ExcludeBlocked(v, "posts.user_id")}, scopes...)
+	return s.db.Model(&models.Post{}).Scopes(scopes...)
+}
+
+// Feed is the scope set for the public timeline as seen by v.
+func Feed(v Viewer) []func(*gorm.DB) *gorm.DB {
+	return []func(*gorm.DB) *gorm.DB{PublishedPosts, ListedPosts(v), ExcludeMuted(v, "posts.user_id")}
+}
+
+// ByAuthor narrows a listing to one author's published, listed posts.
+func ByAuthor(v Viewer, userID uint) []func(*gorm.DB) *gorm.DB {
+	return []func(*gorm.DB) *gorm.DB{PublishedPosts, ListedPosts(v), func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.user_id = ?", userID)
+	}}
+}
+
+// LikedBy narrows a listing to the published, listed posts userID has liked.
+func LikedBy(v Viewer, userID uint) []func(*gorm.DB) *gorm.DB {
+	return []func(*gorm.DB) *gorm.DB{PublishedPosts, ListedPosts(v), func(db *gorm.DB) *gorm.DB {
+		return db.Where("posts.id IN (SELECT post_id FROM likes WHERE user_id = ?)", userID)
+	}}
+}
+
+// RemovePost removes a post together with its comments, likes, bookmarks,
+// link preview, recommendations, poll and plain reposts, and takes it off
+// the repost count of the post it shares. Run it inside a transaction.
+func RemovePost(tx *gorm.DB, postID uint) error {
+	var post models.Post
+	if err := tx.Select("id, repost_of_id, quote_of_id").First(&post, postID).Error; err != nil {
+		return err
+	}
+	if id := originalID(post); id != nil {
+		if err := AdjustCounter(tx, *id, "repost_count", -1); err != nil {
+			return err
+		}
+	}
+	if err := RemoveReposts(tx, []uint{postID}); err != nil {
+		return err
+	}
+
+	if err := tx.Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.Like{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.Bookmark{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.LinkPreview{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ? OR related_id = ?", postID, postID).Delete(&models.RelatedPost{}).Error; err != nil {
+		return err
+	}
+	if err := RemovePolls(tx, []uint{postID}); err != nil {
+		return err
+	}
+	return tx.Delete(&models.Post{}, postID).Error
+}
+
+// AdjustCounter moves one of the denormalized post counters by delta and
+// stamps activity_at. It leaves updated_at alone since engagement is not an
+// edit.
+func AdjustCounter(tx *gorm.DB, postID uint, column string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	return tx.Model(&models.Post{}).Where("id = ?", postID).
+		UpdateColumns(map[string]interface{}{
+			column:        gorm.Expr(column+" + ?", delta),
+			"activity_at": gorm.Expr("NOW()"),
+		}).Error
+}
+
+// IsForbidden reports whether err means v lacks permission.
+func IsForbidden(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == CodeForbidden
+}
+
+// IsNotFound reports whether err means the record does not exist.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.Is(err, gorm.ErrRecordNotFound) || (errors.As(err, &e) && e.Code == CodeNotFound)
+}
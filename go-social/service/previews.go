@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueueLinkPreview keeps a post's preview in step with the first link in
+// its rendered body: a new link is queued for the unfurler, an unchanged one
+// keeps its card and a post without links has none.
+func QueueLinkPreview(tx *gorm.DB, postID uint, bodyHTML string) error {
+	link := utils.FirstLink(bodyHTML)
+	if link == "" || len(link) > 2048 {
+		return tx.Where("post_id = ?", postID).Delete(&models.LinkPreview{}).Error
+	}
+
+	var existing models.LinkPreview
+	if tx.Where("post_id = ?", postID).Take(&existing).Error == nil && existing.URL == link {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "post_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"url":         link,
+			"status":      models.PreviewPending,
+			"title":       "",
+			"description": "",
+			"image_url":   "",
+			"site_name":   "",
+			"started_at":  nil,
+			"fetched_at":  nil,
+			"updated_at":  gorm.Expr("NOW()"),
+		}),
+	}).Create(&models.LinkPreview{PostID: postID, URL: link, Status: models.PreviewPending}).Error
+}
+
+// AttachLinkPreviews sets LinkPreview on each post that has a ready card,
+// in one query.
+func AttachLinkPreviews(db *gorm.DB, posts []models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	var previews []models.LinkPreview
+	db.Where("post_id IN ? AND status = ?", postIDs, models.PreviewReady).Find(&previews)
+
+	byPost := make(map[uint]*models.LinkPreview, len(previews))
+	for i := range previews {
+		byPost[previews[i].PostID] = &previews[i]
+	}
+	for i := range posts {
+		posts[i].LinkPreview = byPost[posts[i].ID]
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+const (
+	SortNew           = "new"
+	SortTop           = "top"
+	SortHot           = "hot"
+	SortControversial = "controversial"
+)
+
+var rankingWindows = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+// controversy favours posts that draw discussion out of proportion to
+// likes: ten comments and no likes outrank ten comments and a hundred likes.
+const controversy = "(posts.comments_count::float8 * posts.comments_count / (posts.likes_count + posts.comments_count))"
+
+// Ranking orders a listing of published posts. Every sort breaks ties on
+// the post ID, so the order is total and pages neither repeat nor skip
+// posts with equal keys.
+type Ranking struct {
+	Sort   string
+	Window string
+}
+
+// ParseRanking validates the sort and window query parameters. Empty
+// values mean newest first over all time.
+func ParseRanking(sort, window string) (Ranking, error) {
+	if sort == "" {
+		sort = SortNew
+	}
+	if window == "" {
+		window = "all"
+	}
+	switch sort {
+	case SortNew, SortTop, SortHot, SortControversial:
+	default:
+		return Ranking{}, invalid("sort must be one of new, top, hot, controversial")
+	}
+	if _, ok := rankingWindows[window]; !ok {
+		return Ranking{}, invalid("window must be one of day, week, month, all")
+	}
+	return Ranking{Sort: sort, Window: window}, nil
+}
+
+// key is the SQL expression posts are ranked by, highest first.
+func (r Ranking) key() string {
+	switch r.Sort {
+	case SortTop:
+		return "posts.likes_count"
+	case SortHot:
+		return "posts.hot_score"
+	case SortControversial:
+		return controversy
+	default:
+		return "posts.published_at"
+	}
+}
+
+// Scope keeps to the posts published inside the window, and for the
+// controversial sort to posts with any comments at all.
+func (r Ranking) Scope(db *gorm.DB) *gorm.DB {
+	if d := rankingWindows[r.Window]; d > 0 {
+		db = db.Where("posts.published_at >= ?", time.Now().Add(-d))
+	}
+	if r.Sort == SortControversial {
+		db = db.Where("posts.comments_count > 0")
+	}
+	return db
+}
+
+func (r Ranking) Order() string {
+	return r.key() + " DESC, posts.id DESC"
+}
+
+// Cursor is an opaque position just past post in this ranking. Paging by
+// cursor instead of offset keeps pages consistent while likes and scores
+// move underneath.
+func (r Ranking) Cursor(post models.Post) string {
+	var key string
+	switch r.Sort {
+	case SortTop:
+		key = strconv.Itoa(post.LikesCount)
+	case SortHot:
+		key = strconv.FormatFloat(post.HotScore, 'g', -1, 64)
+	case SortControversial:
+		c, l := float64(post.CommentsCount), float64(post.LikesCount)
+		key = strconv.FormatFloat(c*c/(l+c), 'g', -1, 64)
+	default:
+		at := post.CreatedAt
+		if post.PublishedAt != nil {
+			at = *post.PublishedAt
+		}
+		key = strconv.FormatInt(at.UnixMicro(), 10)
+	}
+	return encodeCursor("%s:%s:%d", r.Sort, key, post.ID)
+}
+
+// After narrows query to the posts ranked below cursor.
+func (r Ranking) After(query *gorm.DB, cursor string) (*gorm.DB, error) {
+	fields, err := cursorFields(cursor, 3)
+	if err != nil || fields[0] != r.Sort {
+		return nil, invalid("invalid cursor")
+	}
+	id, err := strconv.ParseUint(fields[2], 10, 32)
+	if err != nil {
+		return nil, invalid("invalid cursor")
+	}
+
+	var key interface{}
+	switch r.Sort {
+	case SortNew:
+		var micros int64
+		micros, err = strconv.ParseInt(fields[1], 10, 64)
+		key = time.UnixMicro(micros)
+	case SortTop:
+		key, err = strconv.Atoi(fields[1])
+	default:
+		key, err = strconv.ParseFloat(fields[1], 64)
+	}
+	if err != nil {
+		return nil, invalid("invalid cursor")
+	}
+
+	return query.Where("("+r.key()+", posts.id) < (?, ?)", key, uint(id)), nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"time"
+
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repost shares a post on v's timeline and returns the repost with the
+// original attached. Reposting the same post again returns the existing
+// repost, and reposting a repost shares its original.
+func (s *Service) Repost(v Viewer, postID uint) (models.Post, error) {
+	original, err := s.repostable(v, postID)
+	if err != nil {
+		return models.Post{}, err
+	}
+
+	now := time.Now()
+	repost := models.Post{
+		UserID:      v.ID,
+		Status:      models.StatusPublished,
+		PublishedAt: &now,
+		LiveAt:      &now,
+		Visibility:  models.VisibilityPublic,
+		RepostOfID:  &original.ID,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// The partial unique index on (user_id, repost_of_id) settles
+		// concurrent reposts of the same post
+		result := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "user_id"}, {Name: "repost_of_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "repost_of_id IS NOT NULL"}}},
+			DoNothing:   true,
+		}).Create(&repost)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Where("user_id = ? AND repost_of_id = ?", v.ID, original.ID).First(&repost).Error
+		}
+		return AdjustCounter(tx, original.ID, "repost_count", 1)
+	})
+	if err != nil {
+		return models.Post{}, internal("failed to repost", err)
+	}
+
+	return s.GetPost(v, repost.ID)
+}
+
+// Unrepost takes back v's plain repost of a post. Quotes are deleted like
+// any other post.
+func (s *Service) Unrepost(v Viewer, postID uint) error {
+	if v.ID == 0 {
+		return unauthenticated()
+	}
+
+	var repost models.Post
+	if err := s.db.Where("user_id = ? AND repost_of_id = ?", v.ID, postID).First(&repost).Error; err != nil {
+		return notFound("repost not found")
+	}
+
+	if err := s.db.Transaction(func(tx *gorm.DB) error {
+		return RemovePost(tx, repost.ID)
+	}); err != nil {
+		return internal("failed to undo repost", err)
+	}
+	return nil
+}
+
+// repostable loads the post v wants to repost or quote. A plain repost
+// stands for its original. Only published public posts can be shared.
+func (s *Service) repostable(v Viewer, postID uint) (models.Post, error) {
+	if v.ID == 0 {
+		return models.Post{}, unauthenticated()
+	}
+
+	post, err := FindVisiblePost(s.db, v, postID)
+	if err == nil && post.RepostOfID != nil {
+		post, err = FindVisiblePost(s.db, v, *post.RepostOfID)
+	}
+	if err != nil {
+		return models.Post{}, notFound("post not found")
+	}
+
+	if post.Status != models.StatusPublished || post.Visibility != models.VisibilityPublic {
+		return models.Post{}, forbidden("only public posts can be shared")
+	}
+	if IsBlocked(s.db, v.ID, post.UserID) {
+		return models.Post{}, forbidden("you cannot interact with this user")
+	}
+	return post, nil
+}
+
+// AttachOriginals sets Original on each repost and quote among posts to the
+// post it refers to, with its author, link preview and poll, when v can see
+// it. Originals that no longer exist are flagged with OriginalDeleted.
+func (s *Service) AttachOriginals(v Viewer, posts []models.Post) {
+	var ids []uint
+	for _, post := range posts {
+		if id := originalID(post); id != nil {
+			ids = append(ids, *id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var originals []models.Post
+	s.Posts(v).Preload("User").Where("posts.id IN ?", ids).Find(&originals)
+	AttachLinkPreviews(s.db, originals)
+	AttachPolls(s.db, v, originals)
+
+	var existing []uint
+	s.db.Model(&models.Post{}).Where("id IN ?", ids).Pluck("id", &existing)
+	exists := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	byID := make(map[uint]*models.Post, len(originals))
+	for i := range originals {
+		byID[originals[i].ID] = &originals[i]
+	}
+	for i := range posts {
+		if id := originalID(posts[i]); id != nil {
+			posts[i].Original = byID[*id]
+			posts[i].OriginalDeleted = !exists[*id]
+		}
+	}
+}
+
+func originalID(post models.Post) *uint {
+	if post.RepostOfID != nil {
+		return post.RepostOfID
+	}
+	return post.QuoteOfID
+}
+
+// RemoveReposts removes the plain reposts of postIDs, which have nothing
+// left to show once the original is gone. Quotes stay and render as
+// stubs. Run it inside a transaction.
+func RemoveReposts(tx *gorm.DB, postIDs []uint) error {
+	var reposts []uint
+	if err := tx.Model(&models.Post{}).Where("repost_of_id IN ?", postIDs).Pluck("id", &reposts).Error; err != nil {
+		return err
+	}
+	for _, id := range reposts {
+		if err := RemovePost(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
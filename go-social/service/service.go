@@ -0,0 +1,15 @@
+// Package service holds the domain rules shared by every transport: who may
+// see which posts and comments, and how posts, comments and likes are
+// created, changed and removed. The REST handlers, GraphQL resolvers and
+// gRPC server only translate requests and responses.
+package service
+
+import "gorm.io/gorm"
+
+type Service struct {
+	db *gorm.DB
+}
+
+func New(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
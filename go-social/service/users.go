@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/krisn2/go-social/models"
+	"github.com/krisn2/go-social/utils"
+	"gorm.io/gorm"
+)
+
+// FindUser resolves a reference that is either a numeric user ID or an
+// @handle, returning gorm.ErrRecordNotFound if there is no such user.
+func FindUser(db *gorm.DB, ref string) (models.User, error) {
+	var user models.User
+
+	if strings.HasPrefix(ref, "@") {
+		handle, err := utils.NormalizeHandle(ref)
+		if err != nil {
+			return user, gorm.ErrRecordNotFound
+		}
+		err = db.Where("handle = ?", handle).First(&user).Error
+		return user, err
+	}
+
+	id, err := strconv.ParseUint(ref, 10, 32)
+	if err != nil {
+		return user, gorm.ErrRecordNotFound
+	}
+	err = db.First(&user, uint(id)).Error
+	return user, err
+}
+
+// GetUser looks a user up by ID or @handle.
+func (s *Service) GetUser(ref string) (models.User, error) {
+	user, err := FindUser(s.db, ref)
+	if err != nil {
+		return user, notFound("user not found")
+	}
+	return user, nil
+}
+
+// UsersByID loads users in one query, keyed by ID.
+func (s *Service) UsersByID(ids []uint) (map[uint]models.User, error) {
+	users := make(map[uint]models.User, len(ids))
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	var list []models.User
+	if err := s.db.Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, internal("failed to fetch users", err)
+	}
+	for _, user := range list {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+// PostsByID loads the posts among ids that v can see, keyed by ID.
+func (s *Service) PostsByID(v Viewer, ids []uint) (map[uint]models.Post, error) {
+	posts := make(map[uint]models.Post, len(ids))
+	if len(ids) == 0 {
+		return posts, nil
+	}
+
+	var list []models.Post
+	if err := s.Posts(v).Where("posts.id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, internal("failed to fetch posts", err)
+	}
+	for _, post := range list {
+		posts[post.ID] = post
+	}
+	return posts, nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"github.com/krisn2/go-social/models"
+	"gorm.io/gorm"
+)
+
+// Viewer is the caller of a request. The zero value is an anonymous visitor.
+type Viewer struct {
+	ID   uint
+	Role string
+
+	db *gorm.DB // for follow checks; nil for anonymous visitors
+}
+
+// NewViewer returns the viewer for a user whose role is already known.
+func NewViewer(db *gorm.DB, userID uint, role string) Viewer {
+	return Viewer{ID: userID, Role: role, db: db}
+}
+
+// LoadViewer returns the viewer for userID, which is 0 for anonymous
+// callers.
+func LoadViewer(db *gorm.DB, userID uint) Viewer {
+	if userID == 0 {
+		return Viewer{}
+	}
+
+	v := Viewer{ID: userID, db: db}
+	db.Model(&models.User{}).Select("role").Where("id = ?", userID).Scan(&v.Role)
+	return v
+}
+
+func (v Viewer) IsAdmin() bool {
+	return v.Role == models.RoleAdmin
+}
+
+func (v Viewer) IsModerator() bool {
+	return v.Role == models.RoleModerator || v.IsAdmin()
+}
+
+// CanSeePrivate reports whether v may see the user's email and settings.
+func (v Viewer) CanSeePrivate(user models.User) bool {
+	return v.ID != 0 && (v.ID == user.ID || v.IsAdmin())
+}
+
+// CanSeeActivity reports whether v may browse the user's posts, comments and
+// profile details. Private accounts show them to approved followers only.
+func (v Viewer) CanSeeActivity(user models.User) bool {
+	if !user.Privacy.PrivateAccount || v.CanSeePrivate(user) {
+		return true
+	}
+	return v.ID != 0 && v.db != nil && IsFollowing(v.db, v.ID, user.ID)
+}
+
+// CanSeeLikes reports whether v may browse the posts the user has liked.
+func (v Viewer) CanSeeLikes(user models.User) bool {
+	return v.CanSeeActivity(user) && (!user.Privacy.HideLikes || v.CanSeePrivate(user))
+}
+
+// VisiblePosts applies every read rule for posts. Authors always see their
+// own posts. Everyone else sees only published posts that are public,
+// unlisted, or followers-only with v among the followers. On top of that,
+// posts by private accounts are limited to their approved followers, posts
+// in private communities v is not a member of to admins, and posts hidden
+// by moderation to moderators.
+func VisiblePosts(v Viewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("(posts.status = ? OR posts.user_id = ?)", models.StatusPublished, v.ID)
+		db = db.Where(`(posts.user_id = ? OR posts.visibility IN ? OR
+			(posts.visibility = ? AND posts.user_id IN (SELECT following_id FROM follows WHERE follower_id = ? AND NOT pending)))`,
+			v.ID, []string{models.VisibilityPublic, models.VisibilityUnlisted}, models.VisibilityFollowers, v.ID)
+		if !v.IsModerator() {
+			db = db.Where("(NOT posts.hidden OR posts.user_id = ?)", v.ID)
+		}
+		if !v.IsAdmin() {
+			db = db.Where(`(posts.user_id = ? OR posts.user_id NOT IN (SELECT id FROM users WHERE privacy_private_account) OR
+				posts.user_id IN (SELECT following_id FROM follows WHERE follower_id = ? AND NOT pending))`, v.ID, v.ID)
+			db = db.Where(`(posts.community_id IS NULL OR posts.user_id = ? OR
+				posts.community_id IN (SELECT id FROM communities WHERE membership = ?) OR
+				posts.community_id IN (SELECT community_id FROM community_members WHERE user_id = ?))`,
+				v.ID, models.MembershipPublic, v.ID)
+		}
+		return db
+	}
+}
+
+// PublishedPosts keeps listings to published posts, even for their authors.
+func PublishedPosts(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.status = ?", models.StatusPublished)
+}
+
+// ListedPosts leaves unlisted posts out of listings, except the author's own.
+func ListedPosts(v Viewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(posts.visibility <> ? OR posts.user_id = ?)", models.VisibilityUnlisted, v.ID)
+	}
+}
+
+// VisibleComments hides comments removed by moderation from everyone but
+// their authors and moderators.
+func VisibleComments(v Viewer) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v.IsModerator() {
+			return db
+		}
+		return db.Where("(NOT comments.hidden OR comments.user_id = ?)", v.ID)
+	}
+}
+
+// ExcludeBlocked drops rows whose author column names a user v has blocked
+// or who has blocked v.
+func ExcludeBlocked(v Viewer, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v.ID == 0 {
+			return db
+		}
+		return db.Where(column+" NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ? UNION SELECT blocker_id FROM blocks WHERE blocked_id = ?)", v.ID, v.ID)
+	}
+}
+
+// ExcludeMuted drops rows whose author column names a user v has muted.
+func ExcludeMuted(v Viewer, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if v.ID == 0 {
+			return db
+		}
+		return db.Where(column+" NOT IN (SELECT muted_id FROM mutes WHERE muter_id = ?)", v.ID)
+	}
+}
+
+// FindVisiblePost loads a post by ID, returning gorm.ErrRecordNotFound when
+// it does not exist or v is not allowed to see it.
+func FindVisiblePost(db *gorm.DB, v Viewer, id interface{}) (models.Post, error) {
+	var post models.Post
+	err := db.Model(&models.Post{}).Scopes(VisiblePosts(v)).Where("posts.id = ?", id).First(&post).Error
+	return post, err
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func IsBlocked(db *gorm.DB, a, b uint) bool {
+	var count int64
+	db.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count)
+	return count > 0
+}
+
+// IsFollowing reports whether follower follows following. Follow requests
+// still waiting for approval do not count.
+func IsFollowing(db *gorm.DB, follower, following uint) bool {
+	var count int64
+	db.Model(&models.Follow{}).Where("follower_id = ? AND following_id = ? AND NOT pending", follower, following).Count(&count)
+	return count > 0
+}
+
+// BookmarkedSet returns which of postIDs userID has bookmarked.
+func BookmarkedSet(db *gorm.DB, userID uint, postIDs []uint) map[uint]bool {
+	return postSet(db.Model(&models.Bookmark{}), userID, postIDs)
+}
+
+// LikedSet returns which of postIDs userID has liked.
+func LikedSet(db *gorm.DB, userID uint, postIDs []uint) map[uint]bool {
+	return postSet(db.Model(&models.Like{}), userID, postIDs)
+}
+
+func postSet(query *gorm.DB, userID uint, postIDs []uint) map[uint]bool {
+	set := make(map[uint]bool)
+	if userID == 0 || len(postIDs) == 0 {
+		return set
+	}
+
+	var ids []uint
+	query.Where("user_id = ? AND post_id IN ?", userID, postIDs).Pluck("post_id", &ids)
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
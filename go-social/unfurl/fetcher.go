@@ -0,0 +1,165 @@
+// Package unfurl fetches the OpenGraph and Twitter card metadata used for
+// link previews.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Metadata is what a page says about itself.
+type Metadata struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher loads the preview metadata for a URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (Metadata, error)
+}
+
+// ErrBlockedAddress is returned when a URL resolves to an address the
+// fetcher may not connect to.
+var ErrBlockedAddress = errors.New("unfurl: address not allowed")
+
+const maxRedirects = 5
+
+// HTTPFetcher fetches pages over HTTP. Every connection, including those
+// made for redirects, is checked against allow after DNS resolution, so a
+// hostname cannot be pointed at an internal service.
+type HTTPFetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	userAgent string
+}
+
+// NewHTTPFetcher returns a fetcher that gives up after timeout and reads at
+// most maxBytes of each page. allow decides which addresses may be dialled;
+// nil means PublicIP.
+func NewHTTPFetcher(timeout time.Duration, maxBytes int64, userAgent string, allow func(net.IP) bool) *HTTPFetcher {
+	if allow == nil {
+		allow = PublicIP
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allow(ip) {
+				return ErrBlockedAddress
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		// A proxy would be dialled instead of the target and defeat the guard
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &HTTPFetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("unfurl: too many redirects")
+				}
+				return checkScheme(req.URL)
+			},
+		},
+		maxBytes:  maxBytes,
+		userAgent: userAgent,
+	}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if err := checkScheme(u); err != nil {
+		return Metadata{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Metadata{}, fmt.Errorf("unfurl: unexpected status %d", resp.StatusCode)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Metadata{}, fmt.Errorf("unfurl: unsupported content type %q", mediaType)
+	}
+
+	// Anything past the limit is ignored; the metadata lives in the head
+	return Parse(io.LimitReader(resp.Body, f.maxBytes), resp.Request.URL)
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unfurl: unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+var blockedNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // "this" network
+		"100.64.0.0/10",  // carrier-grade NAT
+		"192.0.0.0/24",   // IETF protocol assignments
+		"198.18.0.0/15",  // benchmarking
+		"240.0.0.0/4",    // reserved
+		"64:ff9b::/96",   // NAT64, which can reach private IPv4
+		"64:ff9b:1::/48", // local-use NAT64
+		"2001:db8::/32",  // documentation
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// PublicIP reports whether ip is a globally routable unicast address, which
+// rules out loopback, private, link-local and other special ranges.
+func PublicIP(ip net.IP) bool {
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
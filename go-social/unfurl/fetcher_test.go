@@ -0,0 +1,215 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const page = `<html><head>
+<title>Plain title</title>
+<meta property="og:title" content="OG title">
+<meta property="og:image" content="/img.png">
+</head><body>ignored</body></html>`
+
+// allowLoopback lets the fetcher reach httptest servers, which listen on
+// 127.0.0.1 only.
+func allowLoopback(ip net.IP) bool {
+	return ip.Equal(net.IPv4(127, 0, 0, 1))
+}
+
+func newFetcher(allow func(net.IP) bool, maxBytes int64) *HTTPFetcher {
+	return NewHTTPFetcher(2*time.Second, maxBytes, "test", allow)
+}
+
+func serve(t *testing.T, h http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func htmlPage(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := serve(t, htmlPage(page))
+
+	meta, err := newFetcher(allowLoopback, 1<<20).Fetch(context.Background(), srv.URL+"/post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "OG title" {
+		t.Errorf("Title = %q, want %q", meta.Title, "OG title")
+	}
+	if want := srv.URL + "/img.png"; meta.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", meta.ImageURL, want)
+	}
+}
+
+func TestFetchBlocksLoopbackByDefault(t *testing.T) {
+	var hit bool
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) { hit = true })
+
+	_, err := newFetcher(nil, 1<<20).Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("err = %v, want ErrBlockedAddress", err)
+	}
+	if hit {
+		t.Error("server was reached")
+	}
+}
+
+func TestFetchBlocksRedirectToBlockedAddress(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://127.0.0.2:1/", http.StatusFound)
+	})
+
+	_, err := newFetcher(allowLoopback, 1<<20).Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("err = %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestFetchRejectsScheme(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+	})
+
+	for _, rawURL := range []string{"ftp://example.com/file", "file:///etc/passwd", srv.URL} {
+		_, err := newFetcher(allowLoopback, 1<<20).Fetch(context.Background(), rawURL)
+		if err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+			t.Errorf("Fetch(%q) err = %v, want unsupported scheme", rawURL, err)
+		}
+	}
+}
+
+func TestFetchRejectsContentType(t *testing.T) {
+	srv := serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"title":"no"}`))
+	})
+
+	_, err := newFetcher(allowLoopback, 1<<20).Fetch(context.Background(), srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "unsupported content type") {
+		t.Fatalf("err = %v, want unsupported content type", err)
+	}
+}
+
+func TestFetchStopsAtMaxBytes(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", 1000) + "-->"
+	srv := serve(t, htmlPage("<html><head>"+padding+"<title>Late title</title></head></html>"))
+
+	meta, err := newFetcher(allowLoopback, 512).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "" {
+		t.Errorf("Title = %q, want nothing read past the limit", meta.Title)
+	}
+
+	meta, err = newFetcher(allowLoopback, 4096).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Late title" {
+		t.Errorf("Title = %q, want %q under a larger limit", meta.Title, "Late title")
+	}
+}
+
+func TestParsePrecedence(t *testing.T) {
+	cases := []struct {
+		name string
+		head string
+		want Metadata
+	}{
+		{
+			name: "OpenGraph wins",
+			head: `<title>T</title>
+				<meta name="description" content="D">
+				<meta name="twitter:title" content="TW">
+				<meta name="twitter:description" content="TWD">
+				<meta property="og:title" content="OG">
+				<meta property="og:description" content="OGD">`,
+			want: Metadata{Title: "OG", Description: "OGD"},
+		},
+		{
+			name: "Twitter beats title and description",
+			head: `<title>T</title>
+				<meta name="description" content="D">
+				<meta name="twitter:title" content="TW">
+				<meta name="twitter:description" content="TWD">`,
+			want: Metadata{Title: "TW", Description: "TWD"},
+		},
+		{
+			name: "title and description as a last resort",
+			head: `<title> T
+				spaced </title><meta name="description" content="D">`,
+			want: Metadata{Title: "T spaced", Description: "D"},
+		},
+		{
+			name: "empty OpenGraph values fall through",
+			head: `<title>T</title><meta property="og:title" content=" ">`,
+			want: Metadata{Title: "T"},
+		},
+		{
+			name: "tags in the body are ignored",
+			head: `</head><body><meta property="og:title" content="late">`,
+			want: Metadata{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader("<html><head>"+tc.head+"</head></html>"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("Parse = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	cases := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},          // carrier-grade NAT
+		{"100.127.255.254", false},     // carrier-grade NAT
+		{"64:ff9b::a00:1", false},      // NAT64 of 10.0.0.1
+		{"64:ff9b:1::1", false},        // local-use NAT64
+		{"::ffff:127.0.0.1", false},    // IPv4-mapped loopback
+		{"::ffff:10.0.0.1", false},     // IPv4-mapped private
+		{"::ffff:93.184.216.34", true}, // IPv4-mapped public
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tc := range cases {
+		if got := PublicIP(net.ParseIP(tc.ip)); got != tc.want {
+			t.Errorf("PublicIP(%s) = %v, want %v", tc.ip, got, tc.want)
+		}
+	}
+}
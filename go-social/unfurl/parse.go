@@ -0,0 +1,120 @@
+package unfurl
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Field limits match the link_previews columns.
+const (
+	maxTitle       = 300
+	maxDescription = 1000
+	maxSiteName    = 200
+	maxURL         = 2048
+)
+
+// Parse reads the metadata from the head of an HTML page. OpenGraph tags
+// win over Twitter card tags, which win over <title> and the plain
+// description. base resolves a relative image URL.
+func Parse(r io.Reader, base *url.URL) (Metadata, error) {
+	meta := map[string]string{}
+	var title string
+
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return build(meta, title, base), nil
+			}
+			return Metadata{}, z.Err()
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.DataAtom {
+			case atom.Body:
+				// Everything we want is in the head
+				return build(meta, title, base), nil
+			case atom.Title:
+				inTitle = tt == html.StartTagToken
+			case atom.Meta:
+				var key, content string
+				for _, a := range tok.Attr {
+					switch a.Key {
+					case "property", "name":
+						if key == "" {
+							key = strings.ToLower(strings.TrimSpace(a.Val))
+						}
+					case "content":
+						content = a.Val
+					}
+				}
+				if _, seen := meta[key]; key != "" && !seen {
+					meta[key] = content
+				}
+			}
+
+		case html.EndTagToken:
+			if z.Token().DataAtom == atom.Head {
+				return build(meta, title, base), nil
+			}
+			inTitle = false
+
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(z.Text())
+			}
+		}
+	}
+}
+
+func build(meta map[string]string, title string, base *url.URL) Metadata {
+	first := func(values ...string) string {
+		for _, v := range values {
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	return Metadata{
+		Title:       clean(first(meta["og:title"], meta["twitter:title"], title), maxTitle),
+		Description: clean(first(meta["og:description"], meta["twitter:description"], meta["description"]), maxDescription),
+		ImageURL:    imageURL(first(meta["og:image:secure_url"], meta["og:image"], meta["twitter:image"], meta["twitter:image:src"]), base),
+		SiteName:    clean(meta["og:site_name"], maxSiteName),
+	}
+}
+
+// clean collapses whitespace and cuts s to at most n bytes of valid UTF-8.
+func clean(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > n {
+		s = s[:n]
+	}
+	return strings.ToValidUTF8(s, "")
+}
+
+// imageURL resolves ref against base and keeps it only if it is a web URL.
+func imageURL(ref string, base *url.URL) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || len(u.String()) > maxURL {
+		return ""
+	}
+	return u.String()
+}
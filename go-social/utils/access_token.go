@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// AccessTokenPrefix starts every personal access token, which tells them
+// apart from session JWTs and makes leaked tokens easy to scan for.
+const AccessTokenPrefix = "gsp_"
+
+// GenerateAccessToken returns a new personal access token and the hash to
+// store for it.
+func GenerateAccessToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashAccessToken(token), nil
+}
+
+// HashAccessToken returns the hex SHA-256 of token. Tokens carry 256 bits
+// of randomness, so a fast hash is enough.
+func HashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAccessToken reports whether a bearer token is a personal access token
+// rather than a JWT.
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, AccessTokenPrefix)
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/krisn2/go-social/models"
+)
+
+// PostVersion identifies one edit of a post. It is the prefix of the post's
+// ETag and what If-Match is compared against, so likes and comments landing
+// on a post do not count as the post changing underneath its editor.
+func PostVersion(post models.Post) string {
+	return fmt.Sprintf("%d.%d", post.ID, post.UpdatedAt.UnixMicro())
+}
+
+// PostETag is a weak ETag for a rendered post. Besides the version it covers
+// the counters and the caller-specific parts of the response.
+func PostETag(post models.Post, viewerID uint, extra ...interface{}) string {
+	h := fnv.New64a()
+	fmt.Fprint(h, post.LikesCount, post.CommentsCount, post.ActivityAt.UnixMicro(), viewerID, extra)
+	if post.Poll != nil {
+		// Results appear when the poll closes, with no write to the post
+		fmt.Fprint(h, post.Poll.Closed(time.Now()), post.Poll.MyChoices)
+	}
+	if post.Original != nil {
+		fmt.Fprint(h, PostETag(*post.Original, viewerID))
+	}
+	fmt.Fprint(h, post.OriginalDeleted)
+	return fmt.Sprintf(`W/"%s-%x"`, PostVersion(post), h.Sum64())
+}
+
+// PostLastModified is the latest of the last edit, the last like or comment,
+// the closing of the post's poll and the last change to the embedded
+// author, and to the original of a repost, truncated to the one-second
+// precision of HTTP dates. It covers nothing caller-specific, so only
+// anonymous responses should carry it.
+func PostLastModified(post models.Post) time.Time {
+	t := post.UpdatedAt
+	for _, u := range []time.Time{post.ActivityAt, post.User.UpdatedAt} {
+		if u.After(t) {
+			t = u
+		}
+	}
+	if post.Poll != nil && post.Poll.Closed(time.Now()) && post.Poll.ClosesAt.After(t) {
+		t = post.Poll.ClosesAt
+	}
+	if post.Original != nil {
+		if u := PostLastModified(*post.Original); u.After(t) {
+			t = u
+		}
+	}
+	return t.UTC().Truncate(time.Second)
+}
+
+// ListETag builds a weak ETag from the parts that make up a list response.
+func ListETag(parts ...interface{}) string {
+	h := fnv.New64a()
+	for _, part := range parts {
+		fmt.Fprint(h, part, "|")
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// NotModified sets the validator and caching headers for a GET response and
+// reports whether the request's conditions say the client copy is current,
+// in which case it has already answered 304. Pass a zero lastModified for
+// responses that have no meaningful modification time. Responses rendered
+// for a signed-in caller are marked private.
+func NotModified(c *gin.Context, etag string, lastModified time.Time, private bool) bool {
+	h := c.Writer.Header()
+	h.Set("ETag", etag)
+	h.Add("Vary", "Authorization")
+	if private {
+		h.Set("Cache-Control", "private, no-cache")
+	} else {
+		h.Set("Cache-Control", "public, no-cache")
+	}
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.2.2)
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		if !etagListMatches(inm, etag) {
+			return false
+		}
+	} else if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil || lastModified.After(since) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// IfMatchVersion reports whether an If-Match header value names the given
+// post version. "*" matches any existing post.
+func IfMatchVersion(ifMatch string, post models.Post) bool {
+	version := PostVersion(post)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), `"`)
+		if tag == version || strings.HasPrefix(tag, version+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagListMatches applies the weak comparison used by If-None-Match.
+func etagListMatches(header, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
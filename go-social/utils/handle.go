@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// NormalizeHandle lower-cases a handle and strips a leading "@".
+func NormalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if !handlePattern.MatchString(handle) {
+		return "", errors.New("handle must be 3-30 characters of letters, digits or underscores")
+	}
+	return handle, nil
+}
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// NormalizeSlug lower-cases a community slug and checks its shape.
+func NormalizeSlug(slug string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if len(slug) < 3 || len(slug) > 50 || !slugPattern.MatchString(slug) {
+		return "", errors.New("slug must be 3-50 characters of letters, digits and single hyphens")
+	}
+	return slug, nil
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Post and comment bodies are Markdown limited to paragraphs with hard line
+// breaks, emphasis, strong, strikethrough, inline code, code blocks,
+// blockquotes, lists, headings, horizontal rules, links and bare URLs.
+// Raw HTML is dropped and images are shown as links to the image.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.Strikethrough, extension.Linkify),
+	goldmark.WithParserOptions(parser.WithASTTransformers(util.Prioritized(userContent{}, 1000))),
+	goldmark.WithRendererOptions(gmhtml.WithHardWraps()),
+)
+
+// htmlPolicy is the allowlist every rendered body passes through, so a
+// parser bug cannot let scripts, event handlers or javascript: URLs out.
+var htmlPolicy = func() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "em", "strong", "del", "code", "pre", "blockquote", "ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("start").Matching(regexp.MustCompile(`^[0-9]{1,9}$`)).OnElements("ol")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]{1,30}$`)).OnElements("code")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("rel").Matching(regexp.MustCompile(`^nofollow ugc$`)).OnElements("a")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	return p
+}()
+
+// RenderMarkdown turns a post or comment body into sanitized HTML.
+func RenderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		// Rendering into memory cannot fail; fall back to escaped text
+		return htmlPolicy.Sanitize("<p>" + bluemonday.StrictPolicy().Sanitize(source) + "</p>")
+	}
+	return htmlPolicy.Sanitize(buf.String())
+}
+
+var absoluteHref = regexp.MustCompile(`<a href="(https?://[^"]+)"`)
+
+// FirstLink returns the first absolute web link in a body rendered by
+// RenderMarkdown, or "" if there is none. URLs inside code are not links.
+func FirstLink(bodyHTML string) string {
+	m := absoluteHref.FindStringSubmatch(bodyHTML)
+	if m == nil {
+		return ""
+	}
+	return html.UnescapeString(m[1])
+}
+
+// userContent turns images into links and marks every link as user
+// generated, for the benefit of search engines.
+type userContent struct{}
+
+func (userContent) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	var images []*ast.Image
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			if image, ok := n.(*ast.Image); ok {
+				images = append(images, image)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, image := range images {
+		parent := image.Parent()
+		if insideLink(image) {
+			// Links cannot nest, so the alt text alone takes its place
+			for child := image.FirstChild(); child != nil; child = image.FirstChild() {
+				parent.InsertBefore(parent, image, child)
+			}
+			parent.RemoveChild(parent, image)
+			continue
+		}
+
+		link := ast.NewLink()
+		link.Destination = image.Destination
+		link.Title = image.Title
+		for child := image.FirstChild(); child != nil; child = image.FirstChild() {
+			link.AppendChild(link, child)
+		}
+		if !link.HasChildren() {
+			link.AppendChild(link, ast.NewString(image.Destination))
+		}
+		parent.ReplaceChild(parent, image, link)
+	}
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering && (n.Kind() == ast.KindLink || n.Kind() == ast.KindAutoLink) {
+			n.SetAttributeString("rel", []byte("nofollow ugc"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func insideLink(n ast.Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == ast.KindLink {
+			return true
+		}
+	}
+	return false
+}
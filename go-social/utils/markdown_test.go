@@ -0,0 +1,102 @@
+package utils
+
+import "testing"
+
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "javascript link loses its href",
+			in:   "[x](javascript:alert(1))",
+			want: "<p><a rel=\"nofollow ugc\">x</a></p>\n",
+		},
+		{
+			name: "inline html is dropped, text kept",
+			in:   "a <b onclick=\"x()\">bold</b> c",
+			want: "<p>a bold c</p>\n",
+		},
+		{
+			name: "inline script tags are dropped, their text escaped",
+			in:   "a <script>alert(1)</script> b",
+			want: "<p>a alert(1) b</p>\n",
+		},
+		{
+			name: "html block with handler is dropped",
+			in:   "<div onclick=\"x()\">hi</div>",
+			want: "\n",
+		},
+		{
+			// A line opening with <script> starts a raw HTML block, which
+			// runs to the closing tag's line and takes the rest of that
+			// line with it
+			name: "script block drops the rest of its line",
+			in:   "<script>alert(1)</script> hi",
+			want: "\n",
+		},
+		{
+			name: "text after a script block survives on its own paragraph",
+			in:   "<script>alert(1)</script>\n\nhi",
+			want: "\n<p>hi</p>\n",
+		},
+		{
+			name: "image becomes a link labelled with its alt text",
+			in:   "![alt](https://e.com/i.png)",
+			want: "<p><a href=\"https://e.com/i.png\" rel=\"nofollow ugc\">alt</a></p>\n",
+		},
+		{
+			name: "image without alt text is labelled with its URL",
+			in:   "![](https://e.com/i.png)",
+			want: "<p><a href=\"https://e.com/i.png\" rel=\"nofollow ugc\">https://e.com/i.png</a></p>\n",
+		},
+		{
+			name: "image inside a link leaves its alt text",
+			in:   "[![alt](https://e.com/i.png)](https://e.com)",
+			want: "<p><a href=\"https://e.com\" rel=\"nofollow ugc\">alt</a></p>\n",
+		},
+		{
+			name: "inline links and bare URLs are nofollow ugc",
+			in:   "[a](https://e.com/a) and https://e.com/b",
+			want: "<p><a href=\"https://e.com/a\" rel=\"nofollow ugc\">a</a> and <a href=\"https://e.com/b\" rel=\"nofollow ugc\">https://e.com/b</a></p>\n",
+		},
+		{
+			name: "autolinks are nofollow ugc",
+			in:   "<https://e.com/auto>",
+			want: "<p><a href=\"https://e.com/auto\" rel=\"nofollow ugc\">https://e.com/auto</a></p>\n",
+		},
+		{
+			name: "URLs in code stay text",
+			in:   "```\nhttps://e.com/block\n```",
+			want: "<pre><code>https://e.com/block\n</code></pre>\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RenderMarkdown(tc.in); got != tc.want {
+				t.Errorf("RenderMarkdown(%q)\n got %q\nwant %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFirstLink(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"no links here", ""},
+		{"`https://e.com/code` then https://e.com/real", "https://e.com/real"},
+		{"```\nhttps://e.com/block\n```", ""},
+		{"[m](mailto:a@b.c)", ""},
+		{"[a](https://e.com/a?x=1&y=2) https://e.com/b", "https://e.com/a?x=1&y=2"},
+	}
+
+	for _, tc := range cases {
+		if got := FirstLink(RenderMarkdown(tc.in)); got != tc.want {
+			t.Errorf("FirstLink(RenderMarkdown(%q)) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
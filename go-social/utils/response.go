@@ -2,6 +2,7 @@ package utils
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/krisn2/go-social/models"
@@ -26,32 +27,194 @@ func Paginate(c *gin.Context) (int, int) {
 	return page, pageSize
 }
 
-func PostResponse(post models.Post, likesCount, commentsCount int) gin.H {
+func PostResponse(post models.Post) gin.H {
 	return gin.H{
-		"id":         post.ID,
-		"title":      post.Title,
-		"body":       post.Body,
-		"author":     UserResponse(post.User),
-		"likes":      likesCount,
-		"comments":   commentsCount,
-		"created_at": post.CreatedAt,
-		"updated_at": post.UpdatedAt,
+		"id":           post.ID,
+		"title":        post.Title,
+		"body":         post.Body,
+		"body_html":    post.BodyHTML,
+		"author":       UserResponse(post.User),
+		"likes":        post.LikesCount,
+		"comments":     post.CommentsCount,
+		"repost_count": post.RepostCount,
+		"repost_of_id": post.RepostOfID,
+		"quote_of_id":  post.QuoteOfID,
+		"original":     OriginalResponse(post),
+		"status":       post.Status,
+		"visibility":   post.Visibility,
+		"community_id": post.CommunityID,
+		"publish_at":   post.PublishAt,
+		"published_at": post.PublishedAt,
+		"created_at":   post.CreatedAt,
+		"updated_at":   post.UpdatedAt,
+		"link_preview": LinkPreviewResponse(post.LinkPreview),
+		"poll":         PollResponse(post.Poll),
+	}
+}
+
+// OriginalResponse embeds the post a repost or quote refers to. It is a
+// stub when the original was deleted or the viewer cannot see it, and nil
+// for other posts or when the original was not loaded.
+func OriginalResponse(post models.Post) gin.H {
+	id := post.RepostOfID
+	if id == nil {
+		id = post.QuoteOfID
+	}
+	switch {
+	case id == nil:
+		return nil
+	case post.Original != nil:
+		// Only one level is embedded; deeper originals go by ID
+		original := PostResponse(*post.Original)
+		delete(original, "original")
+		return original
+	case post.OriginalDeleted:
+		return gin.H{"id": *id, "deleted": true}
+	default:
+		return gin.H{"id": *id, "unavailable": true}
 	}
 }
 
+// PollResponse renders a post's poll, or nil when it has none. Counts are
+// only shown to people who voted while the poll is open, and to everyone
+// once it has closed.
+func PollResponse(poll *models.Poll) gin.H {
+	if poll == nil {
+		return nil
+	}
+
+	closed := poll.Closed(time.Now())
+	results := closed || len(poll.MyChoices) > 0
+
+	options := make([]gin.H, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		item := gin.H{"id": option.ID, "text": option.Text}
+		if results {
+			item["votes"] = option.VotesCount
+		}
+		options = append(options, item)
+	}
+
+	response := gin.H{
+		"id":              poll.ID,
+		"multiple_choice": poll.MultipleChoice,
+		"closes_at":       poll.ClosesAt,
+		"closed":          closed,
+		"options":         options,
+		"voted":           len(poll.MyChoices) > 0,
+		"my_choices":      poll.MyChoices,
+	}
+	if results {
+		response["voters"] = poll.VotersCount
+	}
+	return response
+}
+
+// LinkPreviewResponse is the card for a post's first link, or nil while
+// there is none.
+func LinkPreviewResponse(preview *models.LinkPreview) gin.H {
+	if preview == nil {
+		return nil
+	}
+	return gin.H{
+		"url":         preview.URL,
+		"title":       preview.Title,
+		"description": preview.Description,
+		"image_url":   preview.ImageURL,
+		"site_name":   preview.SiteName,
+	}
+}
+
+// UserResponse is the public view of a user embedded in posts and comments.
+// It never includes the email address.
 func UserResponse(user models.User) gin.H {
 	return gin.H{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
+		"id":         user.ID,
+		"name":       user.Name,
+		"handle":     user.Handle,
+		"avatar_url": user.AvatarURL,
+	}
+}
+
+// PrivateUserResponse is the view shown to the user themselves and to admins.
+func PrivateUserResponse(user models.User) gin.H {
+	response := UserResponse(user)
+	response["email"] = user.Email
+	response["role"] = user.Role
+	return response
+}
+
+func ProfileResponse(user models.User, postsCount, likesCount, commentsCount int) gin.H {
+	return gin.H{
+		"id":         user.ID,
+		"name":       user.Name,
+		"handle":     user.Handle,
+		"bio":        user.Bio,
+		"avatar_url": user.AvatarURL,
+		"joined_at":  user.CreatedAt,
+		"posts":      postsCount,
+		"likes":      likesCount,
+		"comments":   commentsCount,
 	}
 }
 
 func CommentResponse(comment models.Comment) gin.H {
 	return gin.H{
 		"id":         comment.ID,
+		"post_id":    comment.PostID,
 		"body":       comment.Body,
+		"body_html":  comment.BodyHTML,
 		"author":     UserResponse(comment.User),
 		"created_at": comment.CreatedAt,
 	}
 }
+
+// ConversationResponse is an inbox entry. Each participant carries their
+// read position, which doubles as a read receipt.
+func ConversationResponse(conversation models.Conversation, participants []models.Participant, last *models.Message, unread int64) gin.H {
+	members := make([]gin.H, 0, len(participants))
+	for _, participant := range participants {
+		members = append(members, gin.H{
+			"user":                 UserResponse(participant.User),
+			"last_read_message_id": participant.LastReadMessageID,
+			"last_read_at":         participant.LastReadAt,
+		})
+	}
+
+	var lastMessage gin.H
+	if last != nil {
+		lastMessage = MessageResponse(*last)
+	}
+
+	return gin.H{
+		"id":           conversation.ID,
+		"is_group":     conversation.IsGroup,
+		"participants": members,
+		"last_message": lastMessage,
+		"unread":       unread,
+		"activity_at":  conversation.ActivityAt,
+		"created_at":   conversation.CreatedAt,
+	}
+}
+
+func MessageResponse(message models.Message) gin.H {
+	return gin.H{
+		"id":              message.ID,
+		"conversation_id": message.ConversationID,
+		"body":            message.Body,
+		"sender":          UserResponse(message.Sender),
+		"created_at":      message.CreatedAt,
+	}
+}
+
+func CommunityResponse(community models.Community, members int64) gin.H {
+	return gin.H{
+		"id":          community.ID,
+		"name":        community.Name,
+		"slug":        community.Slug,
+		"description": community.Description,
+		"membership":  community.Membership,
+		"members":     members,
+		"created_at":  community.CreatedAt,
+	}
+}